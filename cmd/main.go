@@ -2,69 +2,358 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
+	"fmt"
 	"github.com/gofiber/fiber/v2"
-	"github.com/gofiber/fiber/v2/middleware/logger"
-	"log"
-	"server/internal/controller"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"log/slog"
+	"os"
+	"os/signal"
+	"server/internal/config"
 	"server/internal/database"
 	"server/internal/framework"
+	"server/internal/jobs"
+	"server/internal/logging"
+	"server/internal/models"
+	"server/internal/regions"
 	"server/internal/repository"
-	"server/internal/service"
+	"server/internal/tracing"
+	"server/internal/validation"
+	"syscall"
+	"time"
 )
 
+// Exit codes of the server, so supervisors can tell a failed start from a regular stop
+const (
+	exitOK              = 0 // stopped by a signal after draining all requests
+	exitStartupFailure  = 1 // configuration, database, migration or listener failed
+	exitShutdownTimeout = 2 // requests were still running when the shutdown deadline passed
+)
+
+// traceFlushTimeout bounds exporting the remaining spans on exit
+const traceFlushTimeout = 5 * time.Second
+
+// poolCloseTimeout bounds waiting for database connections that requests still use on exit
+const poolCloseTimeout = 5 * time.Second
+
 func main() {
-	log.Println("Starting application...")
+	os.Exit(run())
+}
+
+func run() int {
+	slog.Info("Starting application...")
+
+	// Read the configuration from config file, environment and command line flags
+	cfg, args, err := config.Load(os.Args[1:], os.LookupEnv)
+	if errors.Is(err, flag.ErrHelp) {
+		return exitOK
+	}
+	if err != nil {
+		slog.Error("Invalid configuration", "error", err)
+		return exitStartupFailure
+	}
+	if err := logging.Setup(os.Stdout, cfg.LogFormat, cfg.LogLevel, cfg.AuditQueries); err != nil {
+		slog.Error("Invalid configuration", "error", err)
+		return exitStartupFailure
+	}
+	slog.Info("Configuration", "settings", cfg.Dump())
+
+	// SIGINT and SIGTERM start the shutdown, a second signal kills the process
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Subcommands run once against the database instead of starting the webserver
+	if len(args) > 0 {
+		var err error
+		switch args[0] {
+		case "migrate":
+			err = migrateSchema(ctx, cfg, args[1:])
+		case "reload-regions":
+			err = reloadRegions(ctx, cfg, args[1:])
+		default:
+			err = fmt.Errorf("unknown command %q", args[0])
+		}
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		if err != nil {
+			slog.Error("Command failed", "command", args[0], "error", err)
+			return exitStartupFailure
+		}
+		return exitOK
+	}
+
+	// Export spans, pending spans are flushed after the webserver stopped
+	shutdownTracing, err := tracing.Setup(ctx, cfg.Tracing)
+	if err != nil {
+		slog.Error("Failed to set up tracing", "error", err)
+		return exitStartupFailure
+	}
+	defer func() {
+		flushCtx, cancel := context.WithTimeout(context.Background(), traceFlushTimeout)
+		defer cancel()
+		if err := shutdownTracing(flushCtx); err != nil {
+			slog.Error("Failed to flush spans", "error", err)
+		}
+	}()
+
+	// Load the region hierarchy
+	rootRegion, err := database.LoadRegions(cfg.Regions)
+	if err != nil {
+		slog.Error("Failed to load regions", "error", err)
+		return exitStartupFailure
+	}
+
+	// Init repositories for the selected storage backend
+	var offerRepo repository.OfferRepository
+	var regionRepo repository.RegionRepository
+	var dbPool *pgxpool.Pool
+	switch cfg.Storage {
+	case "postgres":
+		// PostgreSQL connection
+		dbPool, err = database.ConnectDB(ctx, cfg.Database)
+		if err != nil {
+			slog.Error("Failed to connect to database", "error", err)
+			return exitStartupFailure
+		}
+		defer closePool(dbPool)
+
+		// Drop tables if the flag is set
+		if cfg.Database.DropTables {
+			if err := database.DropTables(ctx, dbPool); err != nil {
+				slog.Error("Failed to drop tables", "error", err)
+				return exitStartupFailure
+			}
+			slog.Info("Tables dropped successfully")
+		}
+
+		// Migrate the database, or make sure it was migrated through the migrate command
+		if cfg.Database.Migrate {
+			if err := database.Migrate(ctx, dbPool, rootRegion); err != nil {
+				slog.Error("Failed to migrate database", "error", err)
+				return exitStartupFailure
+			}
+		} else if err := checkSchemaVersion(ctx, dbPool); err != nil {
+			slog.Error("Database is not migrated", "error", err)
+			return exitStartupFailure
+		}
+
+		offerRepo = repository.NewOfferRepository(dbPool)
+		regionRepo = repository.NewRegionRepository(dbPool)
+
+		// static_region_data may have been reloaded since regions.json was written
+		rootRegion, err = regionRepo.GetRegions(ctx)
+		if err != nil {
+			slog.Error("Failed to read regions", "error", err)
+			return exitStartupFailure
+		}
+	case "memory":
+		offerRepo = repository.NewOfferMemoryRepository(regions.NewIndex(rootRegion))
+		regionRepo = repository.NewRegionMemoryRepository(offerRepo)
+	default:
+		slog.Error("Unknown storage backend", "storage", cfg.Storage)
+		return exitStartupFailure
+	}
+
+	// Requests run with this context. It is canceled when the shutdown deadline passes, so that
+	// stuck handlers abort their queries and release their database connections.
+	requestCtx, cancelRequests := context.WithCancel(context.Background())
+	defer cancelRequests()
+
+	// Init components, middleware and routes
+	app := framework.NewApp(framework.Dependencies{
+		Offers:     offerRepo,
+		Regions:    regionRepo,
+		RootRegion: rootRegion,
+		DBPool:     dbPool,
+		Config: fiber.Config{
+			ReadTimeout:  cfg.Server.ReadTimeout,
+			WriteTimeout: cfg.Server.WriteTimeout,
+			IdleTimeout:  cfg.Server.IdleTimeout,
+			BodyLimit:    cfg.Server.BodyLimit,
+		},
+		AdminToken: cfg.Server.AdminToken,
+		Context:    requestCtx,
+	})
+	if cfg.Server.AdminToken == "" {
+		slog.Warn("No admin token configured, the /api/admin routes are disabled")
+	}
+
+	// Purge expired offers in the background. With PostgreSQL an advisory lock keeps other
+	// replicas from purging at the same time, the in-memory repository belongs to this process only.
+	var locker jobs.Locker
+	if dbPool != nil {
+		locker = database.NewAdvisoryLocker(dbPool)
+	}
+	runner := jobs.NewRunner(locker)
+	if cfg.Jobs.ExpiryInterval > 0 {
+		runner.Add(jobs.ExpireOffers(app.Offers, cfg.Jobs.ExpiryInterval, cfg.Jobs.ExpiryBatchSize))
+	}
+	// Regions reloaded by another replica or the reload-regions command reach this one's hierarchy
+	if dbPool != nil && cfg.Jobs.RegionRefreshInterval > 0 {
+		runner.Add(jobs.RefreshRegions(app.Regions, cfg.Jobs.RegionRefreshInterval))
+	}
+	jobCtx, stopJobs := context.WithCancel(ctx)
+	runner.Start(jobCtx)
+	// A running purge is canceled on shutdown, the pool is closed only after it returned
+	defer func() {
+		stopJobs()
+		runner.Wait()
+	}()
+
+	slog.Info("Starting webserver...", "address", cfg.Server.Address)
+
+	// Report ready once the listener is bound
+	app.Hooks().OnListen(func(fiber.ListenData) error {
+		app.Health.SetReady(true)
+		return nil
+	})
+
+	// Start server
+	listenErr := make(chan error, 1)
+	go func() {
+		listenErr <- app.Listen(cfg.Server.Address)
+	}()
+
+	select {
+	case err := <-listenErr:
+		slog.Error("Webserver failed", "error", err)
+		return exitStartupFailure
+	case <-ctx.Done():
+		stop()
+		app.Health.SetReady(false)
+	}
+
+	// Stop accepting connections and wait for running requests. Offers are written synchronously
+	// within their request, so a drained server has no pending ingest. The deferred Close of
+	// the pool runs afterwards, once no handler uses it anymore. Requests still running at the
+	// deadline are canceled, closing the pool waits for them at most poolCloseTimeout.
+	slog.Info("Shutting down, waiting for running requests...", "timeout", cfg.Server.ShutdownTimeout)
+	if err := app.ShutdownWithTimeout(cfg.Server.ShutdownTimeout); err != nil {
+		slog.Error("Shutdown deadline exceeded", "error", err)
+		cancelRequests()
+		return exitShutdownTimeout
+	}
 
-	// Define the dropTables flag
-	dropTables := flag.Bool("dropTables", false, "Drop the tables before starting the application")
-	flag.Parse()
+	slog.Info("Server stopped")
+	return exitOK
+}
+
+// reloadRegions applies a new region tree to static_region_data of a running deployment.
+// Running servers pick it up within their region refresh interval.
+func reloadRegions(ctx context.Context, cfg config.Config, args []string) error {
+	commandLine := flag.NewFlagSet("reload-regions", flag.ContinueOnError)
+	file := commandLine.String("file", cfg.Regions, "Region tree in the format of regions.json (default: the regions.json compiled into the binary)")
+	if err := commandLine.Parse(args); err != nil {
+		return err
+	}
 
-	// PostgreSQL connection
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
+	rootRegion, err := database.LoadRegions(*file)
+	if err != nil {
+		return fmt.Errorf("failed to load regions: %v", err)
+	}
+	if errs := validation.CheckRegionTree(rootRegion); len(errs) > 0 {
+		return fmt.Errorf("invalid region tree: %v", errs)
+	}
 
-	dbPool, err := database.ConnectDB(ctx)
+	dbPool, err := database.ConnectDB(ctx, cfg.Database)
 	if err != nil {
-		log.Fatalf("Failed to connect to database: %v", err)
+		return fmt.Errorf("failed to connect to database: %v", err)
 	}
 	defer dbPool.Close()
 
-	// Drop tables if the flag is set
-	if *dropTables {
-		if err := database.DropTables(ctx, dbPool); err != nil {
-			log.Fatalf("Failed to drop tables: %v", err)
+	diff, err := repository.NewRegionRepository(dbPool).ReplaceRegions(ctx, rootRegion)
+	if err != nil {
+		return fmt.Errorf("failed to reload regions: %v", err)
+	}
+
+	for _, change := range []struct {
+		name    string
+		regions []models.RegionSummary
+	}{{"Added", diff.Added}, {"Renamed", diff.Renamed}, {"Moved", diff.Moved}, {"Removed", diff.Removed}} {
+		for _, region := range change.regions {
+			slog.Info(change.name+" region", "id", region.ID, "name", region.Name)
 		}
-		log.Println("Tables dropped successfully")
 	}
+	if diff.Empty() {
+		slog.Info("Regions are up to date")
+	}
+	return nil
+}
 
-	// Migrate the database
-	if err := database.Migrate(ctx, dbPool); err != nil {
-		log.Fatalf("Failed to migrate database: %v", err)
+// migrateSchema migrates the schema to the latest or a given version, or rolls back migrations.
+func migrateSchema(ctx context.Context, cfg config.Config, args []string) error {
+	commandLine := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	version := commandLine.Int("to", -1, "Migrate up or down to this schema version (0 reverts everything)")
+	rollback := commandLine.Int("rollback", 0, "Revert this many of the most recently applied migrations")
+	if err := commandLine.Parse(args); err != nil {
+		return err
 	}
 
-	// Init components
-	offerRepo := repository.NewOfferRepository(dbPool)
-	offerService := service.NewOfferService(offerRepo)
-	offerController := controller.NewOfferController(offerService)
+	dbPool, err := database.ConnectDB(ctx, cfg.Database)
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %v", err)
+	}
+	defer dbPool.Close()
 
-	log.Println("Starting webserver...")
-	app := fiber.New()
+	switch {
+	case *rollback != 0:
+		err = database.RollbackSchema(ctx, dbPool, *rollback)
+	case *version >= 0:
+		err = database.MigrateSchemaTo(ctx, dbPool, *version)
+	default:
+		err = database.MigrateSchema(ctx, dbPool)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to migrate database: %v", err)
+	}
 
-	// Add logger
-	app.Use(logger.New(logger.Config{
-		Format: "${time} | ${status} | ${latency} | ${ip} | ${method} | ${url} | ${error}\n",
-	}))
+	current, err := database.SchemaVersion(ctx, dbPool)
+	if err != nil {
+		return fmt.Errorf("failed to read schema version: %v", err)
+	}
+	if current > 0 {
+		rootRegion, err := database.LoadRegions(cfg.Regions)
+		if err != nil {
+			return fmt.Errorf("failed to load regions: %v", err)
+		}
+		if err := database.SeedRegions(ctx, dbPool, rootRegion); err != nil {
+			return fmt.Errorf("failed to insert regions: %v", err)
+		}
+	}
+	slog.Info("Schema is at version", "version", current)
+	return nil
+}
 
-	// Register new routes
-	framework.RegisterRoutes(app, offerController)
+// closePool closes the pool, but waits at most poolCloseTimeout for connections that are still
+// in use, pgxpool.Pool.Close would otherwise block until every one of them is released
+func closePool(dbPool *pgxpool.Pool) {
+	closed := make(chan struct{})
+	go func() {
+		dbPool.Close()
+		close(closed)
+	}()
 
-	// Add swagger
-	framework.RegisterSwagger(app)
+	select {
+	case <-closed:
+	case <-time.After(poolCloseTimeout):
+		slog.Error("Database connections are still in use, exiting without closing them", "timeout", poolCloseTimeout)
+	}
+}
 
-	// Start server
-	err = app.Listen(":80")
+// checkSchemaVersion fails when migrations are pending, the server would otherwise run against an outdated schema
+func checkSchemaVersion(ctx context.Context, dbPool *pgxpool.Pool) error {
+	migrations, err := database.SchemaMigrations()
+	if err != nil {
+		return err
+	}
+	current, err := database.SchemaVersion(ctx, dbPool)
 	if err != nil {
-		return
+		return err
+	}
+	if latest := migrations[len(migrations)-1].Version; current != latest {
+		return fmt.Errorf("schema is at version %d, expected %d; run the migrate command", current, latest)
 	}
+	return nil
 }
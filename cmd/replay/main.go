@@ -0,0 +1,127 @@
+// Command replay replays evaluation logs of the Check24 platform (see log_parser/) against the API
+// and reports every search whose response differs from the expected result.
+//
+//	go run ./cmd/replay -url http://localhost:80 log_parser/sample.log
+//	go run ./cmd/replay -inprocess -v log_parser/sample.log
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"net/http"
+	"os"
+	"os/signal"
+	"server/internal/database"
+	"server/internal/framework"
+	"server/internal/logging"
+	"server/internal/regions"
+	"server/internal/replay"
+	"server/internal/repository"
+	"syscall"
+	"time"
+)
+
+// Exit codes, so the replay can gate a CI job
+const (
+	exitOK       = 0 // every request succeeded and matched
+	exitMismatch = 1 // at least one request failed or mismatched
+	exitFailure  = 2 // the replay could not run
+)
+
+func main() {
+	os.Exit(run())
+}
+
+func run() int {
+	commandLine := flag.NewFlagSet("replay", flag.ContinueOnError)
+	baseURL := commandLine.String("url", "http://localhost:80", "Base URL of the running API")
+	inProcess := commandLine.Bool("inprocess", false, "Replay against a new app with the in-memory repository instead of -url")
+	regionsFile := commandLine.String("regions", "", "Region tree for -inprocess (default: the regions.json compiled into the binary)")
+	timeout := commandLine.Duration("timeout", 30*time.Second, "Timeout of a single request against -url")
+	verbose := commandLine.Bool("v", false, "List every failed request with its differing facets")
+	commandLine.Usage = func() {
+		fmt.Fprintf(commandLine.Output(), "Usage: replay [flags] <log file>...\n")
+		commandLine.PrintDefaults()
+	}
+	if err := commandLine.Parse(os.Args[1:]); err != nil {
+		return exitFailure
+	}
+	if commandLine.NArg() == 0 {
+		commandLine.Usage()
+		return exitFailure
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// The request log of the in-process app would drown the report, only warnings are kept
+	if *inProcess {
+		_ = logging.Setup(os.Stderr, "text", "warn", false)
+	}
+
+	failed := false
+	for _, path := range commandLine.Args() {
+		entries, err := readLog(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to read %s: %v\n", path, err)
+			return exitFailure
+		}
+
+		// Every log starts from an empty repository
+		var client replay.Client = &http.Client{Timeout: *timeout}
+		url := *baseURL
+		if *inProcess {
+			app, err := newMemoryApp(*regionsFile)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to create app: %v\n", err)
+				return exitFailure
+			}
+			client, url = replay.AppClient{App: app}, ""
+		}
+
+		report, err := replay.Run(ctx, client, url, entries)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to replay %s: %v\n", path, err)
+			return exitFailure
+		}
+
+		fmt.Printf("== %s\n", path)
+		report.Write(os.Stdout, *verbose)
+		fmt.Println()
+		failed = failed || report.Failed()
+	}
+
+	if failed {
+		return exitMismatch
+	}
+	return exitOK
+}
+
+func readLog(path string) ([]replay.Entry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return replay.ReadLog(file)
+}
+
+// newMemoryApp creates the app on top of the in-memory repository, like -storage memory
+func newMemoryApp(regionsFile string) (*fiber.App, error) {
+	rootRegion, err := database.LoadRegions(regionsFile)
+	if err != nil {
+		return nil, err
+	}
+
+	offerRepo := repository.NewOfferMemoryRepository(regions.NewIndex(rootRegion))
+	app := framework.NewApp(framework.Dependencies{
+		Offers:     offerRepo,
+		Regions:    repository.NewRegionMemoryRepository(offerRepo),
+		RootRegion: rootRegion,
+		Config:     fiber.Config{DisableStartupMessage: true},
+	})
+	app.Health.SetReady(true)
+	return app.App, nil
+}
@@ -0,0 +1,9 @@
+// Package docs embeds the API specification, so the binary serves it regardless of its working directory.
+package docs
+
+import _ "embed"
+
+// OpenAPI is the content of openapi.yaml
+//
+//go:embed openapi.yaml
+var OpenAPI []byte
@@ -0,0 +1,317 @@
+package config
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config contains all settings of the server. Every setting can be given in the config file,
+// as environment variable and as command line flag; later sources override earlier ones.
+type Config struct {
+	Server    Server   `yaml:"server"`
+	Database  Database `yaml:"database"`
+	Tracing   Tracing  `yaml:"tracing"`
+	Jobs      Jobs     `yaml:"jobs"`
+	Storage   string   `yaml:"storage"`   // postgres or memory
+	Regions   string   `yaml:"regions"`   // region tree replacing the embedded regions.json
+	LogFormat string   `yaml:"logFormat"` // json or text
+	LogLevel  string   `yaml:"logLevel"`  // debug, info, warn or error
+	// AuditQueries logs every search with its rendered SQL, parameters, row counts and durations
+	AuditQueries bool `yaml:"auditQueries"`
+}
+
+type Server struct {
+	Address      string        `yaml:"address"`
+	ReadTimeout  time.Duration `yaml:"readTimeout"`
+	WriteTimeout time.Duration `yaml:"writeTimeout"`
+	IdleTimeout  time.Duration `yaml:"idleTimeout"`
+	BodyLimit    int           `yaml:"bodyLimit"` // bytes
+	// ShutdownTimeout bounds how long running requests may take after a shutdown signal
+	ShutdownTimeout time.Duration `yaml:"shutdownTimeout"`
+	// AdminToken is the bearer token of the /api/admin routes, empty disables them
+	AdminToken string `yaml:"adminToken"`
+}
+
+type Database struct {
+	URL               string        `yaml:"url"`
+	MaxConns          int           `yaml:"maxConns"`
+	MinConns          int           `yaml:"minConns"`
+	MaxConnLifetime   time.Duration `yaml:"maxConnLifetime"`
+	MaxConnIdleTime   time.Duration `yaml:"maxConnIdleTime"`
+	HealthCheckPeriod time.Duration `yaml:"healthCheckPeriod"`
+	ConnectTimeout    time.Duration `yaml:"connectTimeout"`
+	Migrate           bool          `yaml:"migrate"`    // apply pending migrations at startup
+	DropTables        bool          `yaml:"dropTables"` // drop all tables before migrating
+}
+
+type Tracing struct {
+	Exporter string `yaml:"exporter"` // none, stdout or otlp
+	Endpoint string `yaml:"endpoint"` // URL of the OTLP/HTTP collector, e.g. http://localhost:4318
+}
+
+type Jobs struct {
+	ExpiryInterval  time.Duration `yaml:"expiryInterval"`  // how often expired offers are purged, 0 disables it
+	ExpiryBatchSize int           `yaml:"expiryBatchSize"` // offers deleted per statement
+
+	RegionRefreshInterval time.Duration `yaml:"regionRefreshInterval"` // how often static_region_data is checked for reloads, 0 disables it
+}
+
+// Default returns the settings used when no source overrides them.
+// The database password is not part of the default URL, pgx reads it from PGPASSWORD.
+func Default() Config {
+	return Config{
+		Server: Server{
+			Address:         ":80",
+			ReadTimeout:     30 * time.Second,
+			WriteTimeout:    30 * time.Second,
+			IdleTimeout:     2 * time.Minute,
+			BodyLimit:       4 * 1024 * 1024,
+			ShutdownTimeout: 15 * time.Second,
+		},
+		Database: Database{
+			URL:               "postgres://postgres@localhost:5432/postgres?sslmode=disable",
+			MaxConns:          10,
+			MinConns:          2,
+			MaxConnLifetime:   time.Hour,
+			MaxConnIdleTime:   30 * time.Minute,
+			HealthCheckPeriod: 30 * time.Second,
+			ConnectTimeout:    10 * time.Second,
+			Migrate:           true,
+		},
+		Tracing: Tracing{
+			Exporter: "none",
+		},
+		Jobs: Jobs{
+			ExpiryInterval:  time.Minute,
+			ExpiryBatchSize: 1000,
+
+			RegionRefreshInterval: 10 * time.Second,
+		},
+		Storage:   "postgres",
+		LogFormat: "json",
+		LogLevel:  "info",
+	}
+}
+
+// setting binds one field of the config to its flag and environment variable
+type setting struct {
+	flag   string
+	env    string
+	usage  string
+	secret bool
+	value  flag.Value
+}
+
+func (c *Config) settings() []setting {
+	return []setting{
+		{flag: "listen", env: "LISTEN_ADDRESS", usage: "Address the webserver listens on", value: (*stringValue)(&c.Server.Address)},
+		{flag: "readTimeout", env: "READ_TIMEOUT", usage: "Maximum duration for reading a request", value: (*durationValue)(&c.Server.ReadTimeout)},
+		{flag: "writeTimeout", env: "WRITE_TIMEOUT", usage: "Maximum duration for writing a response", value: (*durationValue)(&c.Server.WriteTimeout)},
+		{flag: "idleTimeout", env: "IDLE_TIMEOUT", usage: "Maximum time to wait for the next request on a keep-alive connection", value: (*durationValue)(&c.Server.IdleTimeout)},
+		{flag: "bodyLimit", env: "BODY_LIMIT", usage: "Maximum request body size in bytes", value: (*intValue)(&c.Server.BodyLimit)},
+		{flag: "shutdownTimeout", env: "SHUTDOWN_TIMEOUT", usage: "Maximum duration for draining running requests on shutdown", value: (*durationValue)(&c.Server.ShutdownTimeout)},
+		{flag: "adminToken", env: "ADMIN_TOKEN", usage: "Bearer token required by the /api/admin routes (empty disables them)", secret: true, value: (*stringValue)(&c.Server.AdminToken)},
+		{flag: "databaseURL", env: "DATABASE_URL", usage: "PostgreSQL connection string", secret: true, value: (*stringValue)(&c.Database.URL)},
+		{flag: "dbMaxConns", env: "DB_MAX_CONNS", usage: "Maximum number of pooled connections", value: (*intValue)(&c.Database.MaxConns)},
+		{flag: "dbMinConns", env: "DB_MIN_CONNS", usage: "Number of connections kept open", value: (*intValue)(&c.Database.MinConns)},
+		{flag: "dbMaxConnLifetime", env: "DB_MAX_CONN_LIFETIME", usage: "Duration after which a connection is replaced", value: (*durationValue)(&c.Database.MaxConnLifetime)},
+		{flag: "dbMaxConnIdleTime", env: "DB_MAX_CONN_IDLE_TIME", usage: "Duration after which an idle connection is closed", value: (*durationValue)(&c.Database.MaxConnIdleTime)},
+		{flag: "dbHealthCheckPeriod", env: "DB_HEALTH_CHECK_PERIOD", usage: "Interval of the pool health check", value: (*durationValue)(&c.Database.HealthCheckPeriod)},
+		{flag: "dbConnectTimeout", env: "DB_CONNECT_TIMEOUT", usage: "Maximum duration for establishing a connection", value: (*durationValue)(&c.Database.ConnectTimeout)},
+		{flag: "migrate", env: "MIGRATE", usage: "Apply pending schema migrations at startup", value: (*boolValue)(&c.Database.Migrate)},
+		{flag: "dropTables", env: "DROP_TABLES", usage: "Drop the tables before starting the application", value: (*boolValue)(&c.Database.DropTables)},
+		{flag: "traceExporter", env: "TRACE_EXPORTER", usage: "Exporter for OpenTelemetry spans (none, stdout or otlp)", value: (*stringValue)(&c.Tracing.Exporter)},
+		{flag: "traceEndpoint", env: "TRACE_ENDPOINT", usage: "OTLP/HTTP endpoint of the trace collector (default: OTEL_EXPORTER_OTLP_ENDPOINT or http://localhost:4318)", value: (*stringValue)(&c.Tracing.Endpoint)},
+		{flag: "expiryInterval", env: "EXPIRY_INTERVAL", usage: "Interval of purging expired offers in the background (0 disables it)", value: (*durationValue)(&c.Jobs.ExpiryInterval)},
+		{flag: "expiryBatchSize", env: "EXPIRY_BATCH_SIZE", usage: "Maximum number of expired offers deleted per statement", value: (*intValue)(&c.Jobs.ExpiryBatchSize)},
+		{flag: "regionRefreshInterval", env: "REGION_REFRESH_INTERVAL", usage: "Interval of checking static_region_data for regions reloaded by another server (0 disables it)", value: (*durationValue)(&c.Jobs.RegionRefreshInterval)},
+		{flag: "storage", env: "STORAGE", usage: "Storage backend for offers (postgres or memory)", value: (*stringValue)(&c.Storage)},
+		{flag: "regions", env: "REGIONS_FILE", usage: "Region tree in the format of regions.json (default: the regions.json compiled into the binary)", value: (*stringValue)(&c.Regions)},
+		{flag: "logFormat", env: "LOG_FORMAT", usage: "Format of the log output (json or text)", value: (*stringValue)(&c.LogFormat)},
+		{flag: "logLevel", env: "LOG_LEVEL", usage: "Minimum level of logged messages (debug, info, warn or error)", value: (*stringValue)(&c.LogLevel)},
+		{flag: "auditQueries", env: "AUDIT_QUERIES", usage: "Log the rendered SQL, parameters, row counts and durations of every search", value: (*boolValue)(&c.AuditQueries)},
+	}
+}
+
+// Load builds the configuration from the defaults, the config file, the environment and
+// the command line, in this order. It returns the arguments remaining after the flags.
+func Load(args []string, lookupEnv func(string) (string, bool)) (Config, []string, error) {
+	cfg := Default()
+	settings := cfg.settings()
+
+	// Flags are collected first and applied last, so they override file and environment
+	commandLine := flag.NewFlagSet("server", flag.ContinueOnError)
+	configFile := commandLine.String("config", "", "YAML config file (env CONFIG_FILE)")
+	flags := make([]*deferredValue, len(settings))
+	for i, s := range settings {
+		flags[i] = &deferredValue{target: s.value}
+		commandLine.Var(flags[i], s.flag, fmt.Sprintf("%s (env %s, default %s)", s.usage, s.env, s.value))
+	}
+	if err := commandLine.Parse(args); err != nil {
+		return Config{}, nil, err
+	}
+
+	if *configFile == "" {
+		*configFile, _ = lookupEnv("CONFIG_FILE")
+	}
+	if *configFile != "" {
+		content, err := os.ReadFile(*configFile)
+		if err != nil {
+			return Config{}, nil, fmt.Errorf("failed to read config file: %v", err)
+		}
+		decoder := yaml.NewDecoder(bytes.NewReader(content))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(&cfg); err != nil {
+			return Config{}, nil, fmt.Errorf("failed to parse config file %s: %v", *configFile, err)
+		}
+	}
+
+	for _, s := range settings {
+		if raw, ok := lookupEnv(s.env); ok {
+			if err := s.value.Set(raw); err != nil {
+				return Config{}, nil, fmt.Errorf("invalid value for %s: %v", s.env, err)
+			}
+		}
+	}
+
+	for i, s := range settings {
+		if flags[i].set {
+			if err := s.value.Set(flags[i].raw); err != nil {
+				return Config{}, nil, fmt.Errorf("invalid value for -%s: %v", s.flag, err)
+			}
+		}
+	}
+
+	if err := cfg.validate(); err != nil {
+		return Config{}, nil, err
+	}
+
+	return cfg, commandLine.Args(), nil
+}
+
+// minAdminTokenLength rejects admin tokens that are easy to guess
+const minAdminTokenLength = 16
+
+func (c *Config) validate() error {
+	switch {
+	case c.Storage != "postgres" && c.Storage != "memory":
+		return fmt.Errorf("unknown storage backend: %s", c.Storage)
+	case c.Tracing.Exporter != "none" && c.Tracing.Exporter != "stdout" && c.Tracing.Exporter != "otlp":
+		return fmt.Errorf("unknown trace exporter: %s", c.Tracing.Exporter)
+	case c.LogFormat != "text" && c.LogFormat != "json":
+		return fmt.Errorf("unknown log format: %s", c.LogFormat)
+	case c.LogLevel != "debug" && c.LogLevel != "info" && c.LogLevel != "warn" && c.LogLevel != "error":
+		return fmt.Errorf("unknown log level: %s", c.LogLevel)
+	case c.Server.BodyLimit <= 0:
+		return fmt.Errorf("body limit must be positive")
+	case c.Server.AdminToken != "" && len(c.Server.AdminToken) < minAdminTokenLength:
+		return fmt.Errorf("admin token must have at least %d characters", minAdminTokenLength)
+	case c.Database.MaxConns < 1 || c.Database.MinConns < 0 || c.Database.MinConns > c.Database.MaxConns:
+		return fmt.Errorf("database pool needs 0 <= minConns <= maxConns and maxConns >= 1")
+	case c.Jobs.ExpiryInterval < 0:
+		return fmt.Errorf("expiry interval must not be negative")
+	case c.Jobs.ExpiryBatchSize < 1:
+		return fmt.Errorf("expiry batch size must be positive")
+	case c.Jobs.RegionRefreshInterval < 0:
+		return fmt.Errorf("region refresh interval must not be negative")
+	}
+	return nil
+}
+
+// Dump lists the effective settings, one per line, with secrets masked
+func (c Config) Dump() string {
+	var builder strings.Builder
+	for _, s := range c.settings() {
+		value := s.value.String()
+		if s.secret {
+			value = mask(value)
+		}
+		builder.WriteString(fmt.Sprintf("  %s = %s\n", s.flag, value))
+	}
+	return builder.String()
+}
+
+// mask hides the password of a connection string, or the whole value if it is no URL
+func mask(value string) string {
+	parsed, err := url.Parse(value)
+	if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+		if value == "" {
+			return ""
+		}
+		return "*****"
+	}
+	return parsed.Redacted()
+}
+
+// deferredValue records a flag so that it can be applied after file and environment
+type deferredValue struct {
+	target flag.Value
+	raw    string
+	set    bool
+}
+
+func (d *deferredValue) String() string {
+	if d == nil || d.target == nil {
+		return ""
+	}
+	return d.target.String()
+}
+
+func (d *deferredValue) Set(raw string) error {
+	d.raw, d.set = raw, true
+	return nil
+}
+
+func (d *deferredValue) IsBoolFlag() bool {
+	_, ok := d.target.(*boolValue)
+	return ok
+}
+
+type stringValue string
+
+func (v *stringValue) String() string       { return string(*v) }
+func (v *stringValue) Set(raw string) error { *v = stringValue(raw); return nil }
+
+type intValue int
+
+func (v *intValue) String() string { return strconv.Itoa(int(*v)) }
+func (v *intValue) Set(raw string) error {
+	parsed, err := strconv.Atoi(raw)
+	if err != nil {
+		return fmt.Errorf("%q is not an integer", raw)
+	}
+	*v = intValue(parsed)
+	return nil
+}
+
+type boolValue bool
+
+func (v *boolValue) String() string { return strconv.FormatBool(bool(*v)) }
+func (v *boolValue) Set(raw string) error {
+	parsed, err := strconv.ParseBool(raw)
+	if err != nil {
+		return fmt.Errorf("%q is not a boolean", raw)
+	}
+	*v = boolValue(parsed)
+	return nil
+}
+
+type durationValue time.Duration
+
+func (v *durationValue) String() string { return time.Duration(*v).String() }
+func (v *durationValue) Set(raw string) error {
+	parsed, err := time.ParseDuration(raw)
+	if err != nil {
+		return fmt.Errorf("%q is not a duration", raw)
+	}
+	*v = durationValue(parsed)
+	return nil
+}
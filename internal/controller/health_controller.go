@@ -0,0 +1,26 @@
+package controller
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"server/internal/service"
+)
+
+type HealthController struct {
+	healthService *service.HealthService
+}
+
+func NewHealthController(service *service.HealthService) *HealthController {
+	return &HealthController{healthService: service}
+}
+
+func (hc *HealthController) LivenessHandler(c *fiber.Ctx) error {
+	return c.JSON(hc.healthService.Liveness())
+}
+
+func (hc *HealthController) ReadinessHandler(c *fiber.Ctx) error {
+	response, ready := hc.healthService.Readiness(c.UserContext())
+	if !ready {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(response)
+	}
+	return c.JSON(response)
+}
@@ -1,11 +1,13 @@
 package controller
 
 import (
-	"context"
+	"errors"
 	"github.com/gofiber/fiber/v2"
-	"log"
+	"log/slog"
 	"server/internal/models"
+	"server/internal/repository"
 	"server/internal/service"
+	"server/internal/validation"
 )
 
 type OfferController struct {
@@ -71,81 +73,179 @@ func NewOfferController(service *service.OfferService) *OfferController {
 	return c.Status(fiber.StatusNoContent).SendString("TODO")
 }*/
 func (oc *OfferController) GetOffersHandler(c *fiber.Ctx) error {
-	params := models.OfferFilterParams{
-		RegionID:              c.QueryInt("regionID"),
-		TimeRangeStart:        c.QueryInt("timeRangeStart"),
-		TimeRangeEnd:          c.QueryInt("timeRangeEnd"),
-		NumberDays:            c.QueryInt("numberDays"),
-		SortOrder:             c.Query("sortOrder"),
-		Page:                  c.QueryInt("page"),
-		PageSize:              c.QueryInt("pageSize"),
-		PriceRangeWidth:       c.QueryInt("priceRangeWidth"),
-		MinFreeKilometerWidth: c.QueryInt("minFreeKilometerWidth"),
+	params, errs := validation.ParseOfferQueryParams(c)
+	if len(errs) > 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid query parameters", "fields": errs})
+	}
+
+	response, err := oc.offerService.GetOffers(c.UserContext(), params)
+	if err != nil {
+		slog.ErrorContext(c.UserContext(), "Error fetching offers", "error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "cannot fetch offers"})
 	}
 
-	if minNumberSeats := c.QueryInt("minNumberSeats", 0); minNumberSeats > 0 {
-		params.MinNumberSeats = &minNumberSeats
+	slog.DebugContext(c.UserContext(), "Fetched offers", "offers", len(response.Offers), "query", string(c.Request().URI().QueryString()))
+
+	return c.JSON(response)
+}
+
+// CreateOffersHandler verarbeitet die POST-Anfragen
+func (oc *OfferController) CreateOffersHandler(c *fiber.Ctx) error {
+	//log.Printf("Offers: %v\n", string(c.Body()))
+
+	// Parse and validate the request body
+	offers, invalid, errs := validation.ParseOffersRequest(c.Body())
+	if len(errs) > 0 {
+		slog.WarnContext(c.UserContext(), "Invalid offers", "fields", errs)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid offers", "fields": errs})
 	}
 
-	if minPrice := c.QueryInt("minPrice", 0); minPrice >= 0 {
-		params.MinPrice = &minPrice
+	mode, errs := validation.ParseConflictMode(c.Query("onConflict"))
+	if len(errs) > 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid query parameters", "fields": errs})
 	}
 
-	if maxPrice := c.QueryInt("maxPrice", -1); maxPrice >= 0 {
-		params.MaxPrice = &maxPrice
+	// Call service to create offers
+	response, err := oc.offerService.CreateOffers(c.UserContext(), offers, invalid, mode)
+	if errors.Is(err, repository.ErrDuplicateOffer) {
+		slog.WarnContext(c.UserContext(), "Error creating offers", "error", err)
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err != nil {
+		slog.ErrorContext(c.UserContext(), "Error creating offers", "error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "cannot create offers"})
 	}
 
-	if carType := c.Query("carType"); carType != "" {
-		params.CarType = &carType
+	if len(response.Rejected) > 0 {
+		slog.WarnContext(c.UserContext(), "Rejected offers", "rejected", len(response.Rejected), "offers", len(offers))
 	}
 
-	if onlyVollkasko := c.Query("onlyVollkasko"); onlyVollkasko != "" {
-		onlyVollkasko := onlyVollkasko == "true"
-		params.OnlyVollkasko = &onlyVollkasko
+	return c.Status(fiber.StatusOK).JSON(response)
+}
+
+// DeleteOffersHandler verarbeitet die DELETE-Anfrage.
+func (oc *OfferController) DeleteOffersHandler(c *fiber.Ctx) error {
+	deleted, err := oc.offerService.CleanUpOldOffers(c.UserContext())
+	if err != nil {
+		slog.ErrorContext(c.UserContext(), "Error deleting old offers", "error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "cannot delete old offers"})
 	}
 
-	if minFreeKilometer := c.QueryInt("minFreeKilometer", -1); minFreeKilometer >= 0 {
-		params.MinFreeKilometer = &minFreeKilometer
+	slog.InfoContext(c.UserContext(), "Deleted old offers", "deleted", deleted)
+	return c.Status(fiber.StatusOK).SendString("Old offers were cleaned up successfully")
+}
+
+// DeleteMatchingOffersHandler löscht alle Angebote, die die Filter der Suche erfüllen
+func (oc *OfferController) DeleteMatchingOffersHandler(c *fiber.Ctx) error {
+	deletion, errs := validation.ParseOfferDeleteParams(c)
+	if len(errs) > 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid query parameters", "fields": errs})
 	}
 
-	response, err := oc.offerService.GetOffers(c, params)
+	response, err := oc.offerService.DeleteMatchingOffers(c.UserContext(), deletion)
 	if err != nil {
-		log.Printf("Error fetching offers: %v\n", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "cannot fetch offers"})
+		slog.ErrorContext(c.UserContext(), "Error deleting offers", "error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "cannot delete offers"})
 	}
 
+	slog.InfoContext(c.UserContext(), "Deleted matching offers", "matched", response.Matched, "dryRun", response.DryRun,
+		"query", string(c.Request().URI().QueryString()))
 	return c.JSON(response)
 }
 
-// CreateOffersHandler verarbeitet die POST-Anfragen
-func (oc *OfferController) CreateOffersHandler(c *fiber.Ctx) error {
-	//log.Printf("Offers: %v\n", string(c.Body()))
-	var request struct {
-		Offers []models.Offer `json:"offers"`
+// GetOfferHandler liefert ein einzelnes Angebot mit allen Feldern
+func (oc *OfferController) GetOfferHandler(c *fiber.Ctx) error {
+	id, errs := validation.ParseOfferID(c)
+	if len(errs) > 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid offer ID", "fields": errs})
 	}
 
-	// Parse the request body
-	if err := c.BodyParser(&request); err != nil {
-		log.Printf("Unable to parse JSON: %v\n", err)
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "cannot parse JSON"})
+	offer, err := oc.offerService.GetOffer(c.UserContext(), id)
+	if errors.Is(err, repository.ErrOfferNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "offer not found"})
+	}
+	if err != nil {
+		slog.ErrorContext(c.UserContext(), "Error fetching offer", "error", err, "id", id)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "cannot fetch offer"})
 	}
 
-	// Call service to create offers
-	if err := oc.offerService.CreateOffers(c.Context(), request.Offers); err != nil {
-		log.Printf("Error creating offers: %v\n", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "cannot create offers"})
+	return c.JSON(offer)
+}
+
+// UpdateOfferHandler ändert einzelne Felder eines Angebots
+func (oc *OfferController) UpdateOfferHandler(c *fiber.Ctx) error {
+	id, errs := validation.ParseOfferID(c)
+	if len(errs) > 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid offer ID", "fields": errs})
 	}
 
-	return c.Status(fiber.StatusOK).SendString("Offers were created successfully")
+	patch, errs := validation.ParseOfferPatch(c.Body())
+	if len(errs) > 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid offer", "fields": errs})
+	}
+
+	offer, err := oc.offerService.UpdateOffer(c.UserContext(), id, patch)
+	if errors.Is(err, repository.ErrOfferNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "offer not found"})
+	}
+	if errors.As(err, &errs) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid offer", "fields": errs})
+	}
+	if err != nil {
+		slog.ErrorContext(c.UserContext(), "Error updating offer", "error", err, "id", id)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "cannot update offer"})
+	}
+
+	return c.JSON(offer)
 }
 
-// DeleteOffersHandler verarbeitet die DELETE-Anfrage.
-func (oc *OfferController) DeleteOffersHandler(c *fiber.Ctx) error {
-	ctx := context.Background()
+// DeleteOfferHandler zieht ein einzelnes Angebot zurück
+func (oc *OfferController) DeleteOfferHandler(c *fiber.Ctx) error {
+	id, errs := validation.ParseOfferID(c)
+	if len(errs) > 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid offer ID", "fields": errs})
+	}
 
-	if err := oc.offerService.CleanUpOldOffers(ctx); err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "cannot delete old offers"})
+	deleted, err := oc.offerService.DeleteOffers(c.UserContext(), []string{id})
+	if err != nil {
+		slog.ErrorContext(c.UserContext(), "Error deleting offer", "error", err, "id", id)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "cannot delete offer"})
+	}
+	if len(deleted) == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "offer not found"})
 	}
 
-	return c.Status(fiber.StatusOK).SendString("Old offers were cleaned up successfully")
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// DeleteOffersByIDHandler zieht mehrere Angebote auf einmal zurück. Unbekannte IDs werden im
+// Ergebnis gemeldet, 404 gibt es nur, wenn keine einzige ID existiert.
+func (oc *OfferController) DeleteOffersByIDHandler(c *fiber.Ctx) error {
+	ids, errs := validation.ParseDeleteOffersRequest(c.Body())
+	if len(errs) > 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid offer IDs", "fields": errs})
+	}
+
+	deleted, err := oc.offerService.DeleteOffers(c.UserContext(), ids)
+	if err != nil {
+		slog.ErrorContext(c.UserContext(), "Error deleting offers", "error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "cannot delete offers"})
+	}
+
+	found := make(map[string]bool, len(deleted))
+	for _, id := range deleted {
+		found[id] = true
+	}
+	response := models.DeleteOffersResponse{Deleted: len(deleted), NotFound: []string{}}
+	for _, id := range ids {
+		if !found[id] {
+			response.NotFound = append(response.NotFound, id)
+		}
+	}
+
+	slog.InfoContext(c.UserContext(), "Deleted offers", "deleted", response.Deleted, "notFound", len(response.NotFound))
+	if response.Deleted == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(response)
+	}
+	return c.JSON(response)
 }
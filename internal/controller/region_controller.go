@@ -0,0 +1,64 @@
+package controller
+
+import (
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"log/slog"
+	"server/internal/repository"
+	"server/internal/service"
+	"server/internal/validation"
+)
+
+type RegionController struct {
+	regionService *service.RegionService
+}
+
+func NewRegionController(service *service.RegionService) *RegionController {
+	return &RegionController{regionService: service}
+}
+
+func (rc *RegionController) GetRegionTreeHandler(c *fiber.Ctx) error {
+	return c.JSON(rc.regionService.GetRegionTree())
+}
+
+func (rc *RegionController) GetRegionHandler(c *fiber.Ctx) error {
+	regionID, errs := validation.ParseRegionID(c)
+	if len(errs) > 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid region ID", "fields": errs})
+	}
+
+	region, ok := rc.regionService.GetRegion(regionID)
+	if !ok {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "region not found"})
+	}
+
+	return c.JSON(region)
+}
+
+func (rc *RegionController) SearchRegionsHandler(c *fiber.Ctx) error {
+	name, limit, errs := validation.ParseRegionSearchParams(c)
+	if len(errs) > 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid query parameters", "fields": errs})
+	}
+
+	return c.JSON(rc.regionService.SearchRegions(name, limit))
+}
+
+func (rc *RegionController) ReloadRegionsHandler(c *fiber.Ctx) error {
+	root, errs := validation.ParseRegionTree(c.Body())
+	if len(errs) > 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid region tree", "fields": errs})
+	}
+
+	diff, err := rc.regionService.ReloadRegions(c.UserContext(), root)
+	if errors.Is(err, repository.ErrRegionInUse) {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err != nil {
+		slog.ErrorContext(c.UserContext(), "Error reloading regions", "error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "cannot reload regions"})
+	}
+
+	slog.InfoContext(c.UserContext(), "Reloaded regions", "added", len(diff.Added), "renamed", len(diff.Renamed), "moved", len(diff.Moved), "removed", len(diff.Removed))
+	return c.JSON(diff)
+}
@@ -0,0 +1,11 @@
+package database
+
+import "embed"
+
+// The schema migrations and the default region hierarchy are compiled into the binary
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+//go:embed regions.json
+var defaultRegions []byte
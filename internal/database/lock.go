@@ -0,0 +1,51 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// unlockTimeout bounds releasing an advisory lock, also after the job was canceled
+const unlockTimeout = 5 * time.Second
+
+// AdvisoryLocker coordinates background jobs between replicas with session-level advisory locks.
+// A lock is held on its own connection, so it is released at the latest when the replica dies.
+type AdvisoryLocker struct {
+	pool *pgxpool.Pool
+}
+
+// NewAdvisoryLocker erstellt einen Locker, der die Sperren über den Pool nimmt.
+func NewAdvisoryLocker(pool *pgxpool.Pool) *AdvisoryLocker {
+	return &AdvisoryLocker{pool: pool}
+}
+
+// TryLock takes the advisory lock of name without waiting, ok is false while another session holds it
+func (l *AdvisoryLocker) TryLock(ctx context.Context, name string) (func(), bool, error) {
+	conn, err := l.pool.Acquire(ctx)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to acquire connection: %v", err)
+	}
+
+	var locked bool
+	if err := conn.QueryRow(ctx, "SELECT pg_try_advisory_lock(hashtext($1))", name).Scan(&locked); err != nil {
+		conn.Release()
+		return nil, false, fmt.Errorf("failed to take advisory lock: %v", err)
+	}
+	if !locked {
+		conn.Release()
+		return nil, false, nil
+	}
+
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), unlockTimeout)
+		defer cancel()
+		// A connection that still holds the lock must not go back to the pool
+		if _, err := conn.Exec(ctx, "SELECT pg_advisory_unlock(hashtext($1))", name); err != nil {
+			_ = conn.Conn().Close(ctx)
+		}
+		conn.Release()
+	}, true, nil
+}
@@ -4,7 +4,6 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"os"
 
 	"github.com/jackc/pgx/v4/pgxpool"
@@ -17,72 +16,94 @@ type Region struct {
 	Subregions []Region `json:"subregions"`
 }
 
-// Migrate creates the tables and fills the static_region_data table
-func Migrate(ctx context.Context, pool *pgxpool.Pool) error {
-
-	// Load SQL migrations from file
-	migrationSQL, err := os.ReadFile("internal/database/migrations.sql")
-	if err != nil {
-		return fmt.Errorf("failed to read migrations.sql: %v", err)
+// Migrate applies all pending schema migrations and fills the static_region_data table
+func Migrate(ctx context.Context, pool *pgxpool.Pool, rootRegion Region) error {
+	if err := MigrateSchema(ctx, pool); err != nil {
+		return err
 	}
+	return SeedRegions(ctx, pool, rootRegion)
+}
 
-	// Execute the migration script
-	_, err = pool.Exec(ctx, string(migrationSQL))
-	if err != nil {
-		return fmt.Errorf("failed to execute migrations: %v", err)
+// SeedRegions fills an empty static_region_data table with the region hierarchy
+func SeedRegions(ctx context.Context, pool *pgxpool.Pool, rootRegion Region) error {
+	// Later changes are applied by reloading the regions
+	var seeded bool
+	if err := pool.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM static_region_data)").Scan(&seeded); err != nil {
+		return fmt.Errorf("failed to check static_region_data: %v", err)
+	}
+	if seeded {
+		return nil
 	}
 
-	// Read and parse regions.json
-	file, err := os.Open("internal/database/regions.json")
-	if err != nil {
-		return fmt.Errorf("failed to open regions.json: %v", err)
+	// Insert the root region and its subregions
+	if err := insertRegion(ctx, pool, rootRegion, nil); err != nil {
+		return fmt.Errorf("failed to insert root region: %v", err)
 	}
-	defer func(file *os.File) {
-		err := file.Close()
-		if err != nil {
-			fmt.Printf("failed to close regions.json: %v", err)
-		}
-	}(file)
 
-	byteValue, err := io.ReadAll(file)
-	if err != nil {
-		return fmt.Errorf("failed to read regions.json: %v", err)
+	return nil
+}
+
+// LoadRegions parses the region hierarchy from a file in the format of regions.json.
+// Without a path the regions.json compiled into the binary is used.
+func LoadRegions(path string) (Region, error) {
+	byteValue := defaultRegions
+	if path != "" {
+		var err error
+		if byteValue, err = os.ReadFile(path); err != nil {
+			return Region{}, fmt.Errorf("failed to read %s: %v", path, err)
+		}
+	} else {
+		path = "regions.json"
 	}
 
 	var rootRegion Region
 	if err := json.Unmarshal(byteValue, &rootRegion); err != nil {
-		return fmt.Errorf("failed to unmarshal regions.json: %v", err)
+		return Region{}, fmt.Errorf("failed to unmarshal %s: %v", path, err)
 	}
 
-	// Insert the root region and its subregions
-	if err := insertRegion(ctx, pool, rootRegion, nil); err != nil {
-		return fmt.Errorf("failed to insert root region: %v", err)
-	}
+	return rootRegion, nil
+}
 
-	return nil
+// regionRow is a row of static_region_data
+type regionRow struct {
+	ID       int    `json:"id"`
+	Name     string `json:"name"`
+	ParentID *int   `json:"parent_id"`
 }
 
-// insertRegion inserts a region and its subregions into the static_region_data table
+// insertRegion inserts a region and its subregions into the static_region_data table.
+// All rows are written by one statement, so region_closure is rebuilt only once.
 func insertRegion(ctx context.Context, pool *pgxpool.Pool, region Region, parentID *int) error {
-	_, err := pool.Exec(ctx, "INSERT INTO static_region_data (id, name, parent_id) VALUES ($1, $2, $3) ON CONFLICT (id) DO NOTHING", region.ID, region.Name, parentID)
+	var rows []regionRow
+	collectRegions(region, parentID, &rows)
+	data, err := json.Marshal(rows)
 	if err != nil {
 		return err
 	}
 
+	_, err = pool.Exec(ctx, `
+		INSERT INTO static_region_data (id, name, parent_id)
+		SELECT id, name, parent_id FROM json_to_recordset($1::json) AS r(id INT, name VARCHAR(255), parent_id INT)
+		ON CONFLICT (id) DO NOTHING`, string(data))
+	return err
+}
+
+// collectRegions appends the region and its subregions in pre-order
+func collectRegions(region Region, parentID *int, rows *[]regionRow) {
+	*rows = append(*rows, regionRow{ID: region.ID, Name: region.Name, ParentID: parentID})
 	for _, subregion := range region.Subregions {
-		if err := insertRegion(ctx, pool, subregion, &region.ID); err != nil {
-			return err
-		}
+		collectRegions(subregion, &region.ID, rows)
 	}
-
-	return nil
 }
 
 func DropTables(ctx context.Context, pool *pgxpool.Pool) error {
 	// List of tables to be dropped
 	tables := []string{
 		"offers",
+		"region_closure",
+		"region_version",
 		"static_region_data",
+		"schema_migrations",
 	}
 
 	// Loop through each table and drop it
@@ -93,5 +114,12 @@ func DropTables(ctx context.Context, pool *pgxpool.Pool) error {
 		}
 	}
 
+	// The functions maintaining region_closure are not dropped with the tables
+	for _, function := range []string{"rebuild_region_closure", "refresh_region_closure"} {
+		if _, err := pool.Exec(ctx, "DROP FUNCTION IF EXISTS "+function+"()"); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
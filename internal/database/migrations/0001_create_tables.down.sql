@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS offers;
+DROP TABLE IF EXISTS static_region_data;
@@ -1,3 +1,5 @@
+-- IF NOT EXISTS adopts deployments that were created before migrations were versioned
+
 -- Create offers table
 CREATE TABLE IF NOT EXISTS offers (
     id VARCHAR(40) PRIMARY KEY, -- Unique identifier for each offer
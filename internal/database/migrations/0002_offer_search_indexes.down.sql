@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS offers_end_date_idx;
+DROP INDEX IF EXISTS offers_price_desc_id_idx;
+DROP INDEX IF EXISTS offers_price_id_idx;
+DROP INDEX IF EXISTS offers_region_dates_idx;
@@ -0,0 +1,16 @@
+-- Indexes of the offer search. Every search selects the offers of a region subtree within a
+-- time window, so the region leads and the time window follows as range. The columns of the
+-- facets are included, so that aggregating them does not need to read the table.
+-- The statements run in the migration transaction and block writes to offers until they are built.
+CREATE INDEX IF NOT EXISTS offers_region_dates_idx
+    ON offers (most_specific_region_id, start_date, end_date)
+    INCLUDE (id, price, car_type, number_seats, only_vollkasko, free_kilometers);
+
+-- Pages are sorted by price and then by id. Searches matching large parts of the table read
+-- these in order and stop after the page instead of sorting every match, the same holds for
+-- the keyset condition (price, id) > (...) of cursor pages.
+CREATE INDEX IF NOT EXISTS offers_price_id_idx ON offers (price, id);
+CREATE INDEX IF NOT EXISTS offers_price_desc_id_idx ON offers (price DESC, id);
+
+-- The expiry job deletes offers past their end date in small batches
+CREATE INDEX IF NOT EXISTS offers_end_date_idx ON offers (end_date);
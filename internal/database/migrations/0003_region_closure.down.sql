@@ -0,0 +1,4 @@
+DROP TRIGGER IF EXISTS static_region_data_closure ON static_region_data;
+DROP FUNCTION IF EXISTS rebuild_region_closure();
+DROP FUNCTION IF EXISTS refresh_region_closure();
+DROP TABLE IF EXISTS region_closure;
@@ -0,0 +1,45 @@
+-- Ancestor/descendant pairs of the region tree, every region is its own ancestor with depth 0.
+-- Searches join it to select the offers of a subtree instead of walking static_region_data.
+CREATE TABLE IF NOT EXISTS region_closure (
+    ancestor_id INT NOT NULL,
+    descendant_id INT NOT NULL,
+    depth INT NOT NULL,
+    PRIMARY KEY (ancestor_id, descendant_id)
+);
+
+-- Recomputes region_closure from static_region_data. The tree has a few hundred regions,
+-- so rebuilding it completely is cheaper than maintaining the pairs row by row.
+CREATE OR REPLACE FUNCTION refresh_region_closure() RETURNS void AS $$
+BEGIN
+    DELETE FROM region_closure;
+    INSERT INTO region_closure (ancestor_id, descendant_id, depth)
+    WITH RECURSIVE closure AS (
+        SELECT id AS ancestor_id, id AS descendant_id, 0 AS depth
+        FROM static_region_data
+
+        UNION ALL
+
+        SELECT c.ancestor_id, r.id, c.depth + 1
+        FROM closure c
+        JOIN static_region_data r ON r.parent_id = c.descendant_id
+    )
+    SELECT ancestor_id, descendant_id, depth FROM closure;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION rebuild_region_closure() RETURNS trigger AS $$
+BEGIN
+    PERFORM refresh_region_closure();
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Every statement changing the regions rebuilds the closure in the same transaction,
+-- so searches never see a closure that does not match static_region_data
+DROP TRIGGER IF EXISTS static_region_data_closure ON static_region_data;
+CREATE TRIGGER static_region_data_closure
+    AFTER INSERT OR UPDATE OR DELETE OR TRUNCATE ON static_region_data
+    FOR EACH STATEMENT EXECUTE FUNCTION rebuild_region_closure();
+
+-- Regions that were seeded before
+SELECT refresh_region_closure();
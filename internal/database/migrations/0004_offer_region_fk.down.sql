@@ -0,0 +1 @@
+ALTER TABLE offers DROP CONSTRAINT IF EXISTS offers_region_fk;
@@ -0,0 +1,10 @@
+-- Offers may only reference existing regions. The check of the offer service runs against the
+-- in-memory hierarchy before the insert, the key closes the gap to a concurrent region reload:
+-- a removed region cannot be referenced afterwards and a referenced region cannot be removed.
+-- NOT VALID adds the key without scanning offers under an exclusive lock, the scan follows
+-- and fails if existing offers reference unknown regions.
+ALTER TABLE offers DROP CONSTRAINT IF EXISTS offers_region_fk;
+ALTER TABLE offers
+    ADD CONSTRAINT offers_region_fk FOREIGN KEY (most_specific_region_id)
+    REFERENCES static_region_data (id) NOT VALID;
+ALTER TABLE offers VALIDATE CONSTRAINT offers_region_fk;
@@ -0,0 +1,8 @@
+CREATE OR REPLACE FUNCTION rebuild_region_closure() RETURNS trigger AS $$
+BEGIN
+    PERFORM refresh_region_closure();
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TABLE IF EXISTS region_version;
@@ -0,0 +1,14 @@
+-- Version of static_region_data. Every statement changing the regions increments it in the
+-- same transaction, so servers notice reloads of other replicas and of the reload-regions command.
+CREATE TABLE IF NOT EXISTS region_version (
+    version BIGINT NOT NULL
+);
+INSERT INTO region_version (version) SELECT 0 WHERE NOT EXISTS (SELECT 1 FROM region_version);
+
+CREATE OR REPLACE FUNCTION rebuild_region_closure() RETURNS trigger AS $$
+BEGIN
+    PERFORM refresh_region_closure();
+    UPDATE region_version SET version = version + 1;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
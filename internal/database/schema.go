@@ -0,0 +1,256 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// migrationLockID is the advisory lock that keeps replicas from migrating at the same time
+const migrationLockID = 47210001
+
+var migrationFileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// SchemaMigration is one versioned step of the database schema
+type SchemaMigration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// LoadSchemaMigrations reads all migrations of a directory, ordered by version.
+// Every version needs both an up and a down script.
+func LoadSchemaMigrations(fsys fs.FS) ([]SchemaMigration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %v", err)
+	}
+
+	byVersion := make(map[int]*SchemaMigration)
+	for _, entry := range entries {
+		match := migrationFileName.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+
+		version, _ := strconv.Atoi(match[1])
+		if version <= 0 {
+			return nil, fmt.Errorf("invalid migration version in %s", entry.Name())
+		}
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &SchemaMigration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		}
+		if migration.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has different names: %s and %s", version, migration.Name, match[2])
+		}
+
+		script, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %v", entry.Name(), err)
+		}
+		if match[3] == "up" {
+			migration.Up = string(script)
+		} else {
+			migration.Down = string(script)
+		}
+	}
+
+	migrations := make([]SchemaMigration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" || migration.Down == "" {
+			return nil, fmt.Errorf("migration %d_%s needs an up and a down script", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+
+	return migrations, nil
+}
+
+// SchemaMigrations returns the migrations embedded from the migrations directory,
+// e.g. 0002_add_index.up.sql and 0002_add_index.down.sql
+func SchemaMigrations() ([]SchemaMigration, error) {
+	migrations, err := fs.Sub(migrationFiles, "migrations")
+	if err != nil {
+		return nil, err
+	}
+	return LoadSchemaMigrations(migrations)
+}
+
+// MigrateSchema applies all pending migrations
+func MigrateSchema(ctx context.Context, pool *pgxpool.Pool) error {
+	migrations, err := SchemaMigrations()
+	if err != nil {
+		return err
+	}
+	if len(migrations) == 0 {
+		return nil
+	}
+	return migrateSchema(ctx, pool, migrations, func([]int) int { return migrations[len(migrations)-1].Version })
+}
+
+// MigrateSchemaTo applies or reverts migrations until the schema is at the given version.
+// Version 0 reverts every migration.
+func MigrateSchemaTo(ctx context.Context, pool *pgxpool.Pool, version int) error {
+	migrations, err := SchemaMigrations()
+	if err != nil {
+		return err
+	}
+
+	known := version == 0
+	for _, migration := range migrations {
+		known = known || migration.Version == version
+	}
+	if !known {
+		return fmt.Errorf("unknown schema version %d", version)
+	}
+
+	return migrateSchema(ctx, pool, migrations, func([]int) int { return version })
+}
+
+// RollbackSchema reverts the given number of most recently applied migrations
+func RollbackSchema(ctx context.Context, pool *pgxpool.Pool, steps int) error {
+	if steps < 1 {
+		return fmt.Errorf("invalid number of migrations to roll back: %d", steps)
+	}
+
+	migrations, err := SchemaMigrations()
+	if err != nil {
+		return err
+	}
+
+	return migrateSchema(ctx, pool, migrations, func(applied []int) int {
+		if steps >= len(applied) {
+			return 0
+		}
+		return applied[len(applied)-1-steps]
+	})
+}
+
+// SchemaVersion returns the most recently applied migration, 0 for an empty database
+func SchemaVersion(ctx context.Context, pool *pgxpool.Pool) (int, error) {
+	var exists bool
+	if err := pool.QueryRow(ctx, "SELECT to_regclass('schema_migrations') IS NOT NULL").Scan(&exists); err != nil {
+		return 0, err
+	}
+	if !exists {
+		return 0, nil
+	}
+
+	var version int
+	if err := pool.QueryRow(ctx, "SELECT COALESCE(MAX(version), 0) FROM schema_migrations").Scan(&version); err != nil {
+		return 0, err
+	}
+	return version, nil
+}
+
+// migrateSchema holds the migration lock, determines the target version from the applied
+// versions and then runs every up or down script in its own transaction.
+func migrateSchema(ctx context.Context, pool *pgxpool.Pool, migrations []SchemaMigration, target func(applied []int) int) error {
+	conn, err := pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	if _, err := conn.Exec(ctx, "SELECT pg_advisory_lock($1)", migrationLockID); err != nil {
+		return fmt.Errorf("failed to acquire migration lock: %v", err)
+	}
+	defer func() {
+		if _, err := conn.Exec(context.Background(), "SELECT pg_advisory_unlock($1)", migrationLockID); err != nil {
+			slog.Error("Failed to release migration lock", "error", err)
+		}
+	}()
+
+	_, err = conn.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version BIGINT PRIMARY KEY,
+			name VARCHAR(255) NOT NULL,
+			applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+		)`)
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %v", err)
+	}
+
+	applied, err := appliedVersions(ctx, conn)
+	if err != nil {
+		return err
+	}
+	version := target(applied)
+
+	isApplied := make(map[int]bool, len(applied))
+	for _, v := range applied {
+		isApplied[v] = true
+	}
+	byVersion := make(map[int]SchemaMigration, len(migrations))
+	for _, migration := range migrations {
+		byVersion[migration.Version] = migration
+	}
+
+	// Revert newer migrations first, starting with the most recent one
+	for i := len(applied) - 1; i >= 0 && applied[i] > version; i-- {
+		migration, ok := byVersion[applied[i]]
+		if !ok {
+			return fmt.Errorf("cannot revert schema version %d: migration is unknown", applied[i])
+		}
+		err := conn.BeginFunc(ctx, func(tx pgx.Tx) error {
+			if _, err := tx.Exec(ctx, migration.Down); err != nil {
+				return err
+			}
+			_, err := tx.Exec(ctx, "DELETE FROM schema_migrations WHERE version = $1", migration.Version)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("failed to revert migration %d_%s: %v", migration.Version, migration.Name, err)
+		}
+		slog.InfoContext(ctx, "Reverted migration", "version", migration.Version, "name", migration.Name)
+	}
+
+	for _, migration := range migrations {
+		if migration.Version > version || isApplied[migration.Version] {
+			continue
+		}
+		err := conn.BeginFunc(ctx, func(tx pgx.Tx) error {
+			if _, err := tx.Exec(ctx, migration.Up); err != nil {
+				return err
+			}
+			_, err := tx.Exec(ctx, "INSERT INTO schema_migrations (version, name) VALUES ($1, $2)", migration.Version, migration.Name)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("failed to apply migration %d_%s: %v", migration.Version, migration.Name, err)
+		}
+		slog.InfoContext(ctx, "Applied migration", "version", migration.Version, "name", migration.Name)
+	}
+
+	return nil
+}
+
+// appliedVersions lists the versions recorded in schema_migrations in ascending order
+func appliedVersions(ctx context.Context, conn *pgxpool.Conn) ([]int, error) {
+	rows, err := conn.Query(ctx, "SELECT version FROM schema_migrations ORDER BY version")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema_migrations: %v", err)
+	}
+	defer rows.Close()
+
+	var versions []int
+	for rows.Next() {
+		var version int
+		if err := rows.Scan(&version); err != nil {
+			return nil, err
+		}
+		versions = append(versions, version)
+	}
+	return versions, rows.Err()
+}
@@ -0,0 +1,25 @@
+package framework
+
+import (
+	"crypto/subtle"
+	"github.com/gofiber/fiber/v2"
+	"strings"
+)
+
+// AdminAuth only lets requests through that send the admin token as bearer token.
+// Without a configured token every admin request is refused.
+func AdminAuth(token string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if token == "" {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "admin API is disabled, configure an admin token"})
+		}
+
+		header := c.Get(fiber.HeaderAuthorization)
+		given, ok := strings.CutPrefix(header, "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+			c.Set(fiber.HeaderWWWAuthenticate, "Bearer")
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "missing or invalid admin token"})
+		}
+		return c.Next()
+	}
+}
@@ -0,0 +1,72 @@
+package framework
+
+import (
+	"context"
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"server/internal/controller"
+	"server/internal/database"
+	"server/internal/metrics"
+	"server/internal/regions"
+	"server/internal/repository"
+	"server/internal/service"
+)
+
+// Dependencies are everything the application is built from, the server and the tests
+// differ only in these
+type Dependencies struct {
+	Offers     repository.OfferRepository
+	Regions    repository.RegionRepository
+	RootRegion database.Region // initial region hierarchy, e.g. read from static_region_data
+	DBPool     *pgxpool.Pool   // adds the database health check and pool metrics, nil without PostgreSQL
+	Config     fiber.Config
+	AdminToken string
+	Context    context.Context // base context of every request, canceling it aborts running requests
+}
+
+// App is the wired application with the services the background jobs and probes need
+type App struct {
+	*fiber.App
+	Offers  *service.OfferService
+	Regions *service.RegionService
+	Health  *service.HealthService
+}
+
+// NewApp creates the services and controllers and registers middleware and routes in their order
+func NewApp(deps Dependencies) *App {
+	hierarchy := regions.NewHierarchy(regions.NewIndex(deps.RootRegion))
+	app := &App{
+		App:     fiber.New(deps.Config),
+		Offers:  service.NewOfferService(deps.Offers, hierarchy),
+		Regions: service.NewRegionService(hierarchy, deps.Regions),
+		Health:  service.NewHealthService(deps.Regions),
+	}
+	if deps.DBPool != nil {
+		app.Health.AddCheck("database", deps.DBPool.Ping)
+		metrics.SetPool(deps.DBPool)
+	}
+	metrics.SetOfferCounter(deps.Offers.EstimateOffers)
+
+	offerController := controller.NewOfferController(app.Offers)
+	regionController := controller.NewRegionController(app.Regions)
+	healthController := controller.NewHealthController(app.Health)
+
+	// The request context comes first, every other middleware derives its context from it
+	if deps.Context != nil {
+		RegisterRequestContext(app.App, deps.Context)
+	}
+
+	// Log every request with its request ID
+	RegisterLogging(app.App)
+
+	// Add metrics and tracing before the routes, so every route is measured
+	RegisterMetrics(app.App)
+	RegisterTracing(app.App)
+
+	RegisterRoutes(app.App, offerController, regionController, healthController)
+	RegisterAdminRoutes(app.App, regionController, deps.AdminToken)
+
+	RegisterSwagger(app.App)
+
+	return app
+}
@@ -1,23 +1,72 @@
 package framework
 
 import (
+	"context"
 	"github.com/gofiber/contrib/swagger"
 	"github.com/gofiber/fiber/v2"
+	"server/docs"
 	"server/internal/controller"
+	"server/internal/logging"
+	"server/internal/metrics"
+	"server/internal/tracing"
 )
 
-func RegisterRoutes(app *fiber.App, offerController *controller.OfferController) {
+func RegisterRoutes(app *fiber.App, offerController *controller.OfferController, regionController *controller.RegionController, healthController *controller.HealthController) {
+	app.Get("/healthz", healthController.LivenessHandler)
+	app.Get("/readyz", healthController.ReadinessHandler)
+
 	app.Delete("/api/offers", offerController.DeleteOffersHandler)
 	app.Post("/api/offers", offerController.CreateOffersHandler)
 	app.Get("/api/offers", offerController.GetOffersHandler)
+	app.Post("/api/offers/delete", offerController.DeleteOffersByIDHandler)
+	app.Delete("/api/offers/filter", offerController.DeleteMatchingOffersHandler)
+	app.Get("/api/offers/:id", offerController.GetOfferHandler)
+	app.Patch("/api/offers/:id", offerController.UpdateOfferHandler)
+	app.Delete("/api/offers/:id", offerController.DeleteOfferHandler)
+
+	app.Get("/api/regions", regionController.GetRegionTreeHandler)
+	app.Get("/api/regions/search", regionController.SearchRegionsHandler)
+	app.Get("/api/regions/:id", regionController.GetRegionHandler)
+}
+
+// RegisterAdminRoutes adds the /api/admin routes, which require the admin token
+func RegisterAdminRoutes(app *fiber.App, regionController *controller.RegionController, adminToken string) {
+	admin := app.Group("/api/admin", AdminAuth(adminToken))
+	admin.Put("/regions", regionController.ReloadRegionsHandler)
+}
+
+// RegisterRequestContext derives the context of every request from ctx, canceling ctx aborts the
+// running requests and their database queries. It has to be registered before all other handlers.
+func RegisterRequestContext(app *fiber.App, ctx context.Context) {
+	app.Use(func(c *fiber.Ctx) error {
+		c.SetUserContext(ctx)
+		return c.Next()
+	})
+}
+
+// RegisterLogging assigns request IDs and logs every request to the routes registered afterwards
+func RegisterLogging(app *fiber.App) {
+	app.Use(logging.Middleware())
+}
+
+// RegisterMetrics adds /metrics and measures all routes registered afterwards
+func RegisterMetrics(app *fiber.App) {
+	app.Use(metrics.Middleware())
+	app.Get("/metrics", metrics.Handler())
+}
+
+// RegisterTracing starts a span for every request to the routes registered afterwards
+func RegisterTracing(app *fiber.App) {
+	app.Use(tracing.Middleware())
 }
 
 func RegisterSwagger(app *fiber.App) {
 	cfg := swagger.Config{
-		BasePath: "/",
-		FilePath: "./docs/openapi.yaml",
-		Path:     "openapi",
-		Title:    "Swagger API Docs",
+		BasePath:    "/",
+		FilePath:    "./docs/openapi.yaml",
+		FileContent: docs.OpenAPI,
+		Path:        "openapi",
+		Title:       "Swagger API Docs",
 	}
 
 	app.Use(swagger.New(cfg))
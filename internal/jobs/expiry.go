@@ -0,0 +1,26 @@
+package jobs
+
+import (
+	"context"
+	"log/slog"
+	"server/internal/service"
+	"time"
+)
+
+// ExpireOffersJob is the name of the job purging expired offers
+const ExpireOffersJob = "expire-offers"
+
+// ExpireOffers löscht regelmäßig alle abgelaufenen Angebote, jeweils höchstens batchSize pro Statement.
+func ExpireOffers(offers *service.OfferService, interval time.Duration, batchSize int) Job {
+	return Job{
+		Name:     ExpireOffersJob,
+		Interval: interval,
+		Run: func(ctx context.Context) error {
+			deleted, err := offers.PurgeExpiredOffers(ctx, time.Now(), batchSize)
+			if deleted > 0 {
+				slog.InfoContext(ctx, "Deleted expired offers", "deleted", deleted)
+			}
+			return err
+		},
+	}
+}
@@ -0,0 +1,28 @@
+package jobs
+
+import (
+	"context"
+	"log/slog"
+	"server/internal/service"
+	"time"
+)
+
+// RefreshRegionsJob is the name of the job reloading the region hierarchy
+const RefreshRegionsJob = "refresh-regions"
+
+// RefreshRegions übernimmt regelmäßig Änderungen an static_region_data in die Hierarchie dieser Instanz.
+// Der Job läuft auf jeder Replika, weil jede ihre eigene Hierarchie im Speicher hält.
+func RefreshRegions(regions *service.RegionService, interval time.Duration) Job {
+	return Job{
+		Name:     RefreshRegionsJob,
+		Interval: interval,
+		Local:    true,
+		Run: func(ctx context.Context) error {
+			changed, err := regions.RefreshRegions(ctx)
+			if changed {
+				slog.InfoContext(ctx, "Refreshed regions from the database")
+			}
+			return err
+		},
+	}
+}
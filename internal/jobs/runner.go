@@ -0,0 +1,103 @@
+// Package jobs runs background jobs of the server periodically. With several replicas a Locker
+// makes sure that only one of them runs a job at a time.
+package jobs
+
+import (
+	"context"
+	"log/slog"
+	"server/internal/metrics"
+	"server/internal/tracing"
+	"sync"
+	"time"
+)
+
+// Locker takes a lock per job that is shared by all replicas. ok is false while another replica
+// holds the lock, unlock releases it after the run.
+type Locker interface {
+	TryLock(ctx context.Context, name string) (unlock func(), ok bool, err error)
+}
+
+// Job is a task that runs every Interval, the first time right after Start.
+// Local jobs update state of this replica only, so they run on every replica without the lock.
+type Job struct {
+	Name     string
+	Interval time.Duration
+	Local    bool
+	Run      func(ctx context.Context) error
+}
+
+// Runner runs its jobs in the background until the context of Start is canceled
+type Runner struct {
+	locker Locker
+	jobs   []Job
+	wg     sync.WaitGroup
+}
+
+// NewRunner erstellt einen Runner. Ohne Locker (nil) laufen die Jobs nur lokal, etwa mit dem In-Memory-Repository.
+func NewRunner(locker Locker) *Runner {
+	return &Runner{locker: locker}
+}
+
+// Add registers a job, jobs added after Start are not run
+func (r *Runner) Add(job Job) {
+	r.jobs = append(r.jobs, job)
+}
+
+// Start runs every job in its own goroutine. Runs of the same job never overlap.
+func (r *Runner) Start(ctx context.Context) {
+	for _, job := range r.jobs {
+		r.wg.Add(1)
+		go func(job Job) {
+			defer r.wg.Done()
+			ticker := time.NewTicker(job.Interval)
+			defer ticker.Stop()
+
+			for ctx.Err() == nil {
+				r.run(ctx, job)
+				select {
+				case <-ctx.Done():
+				case <-ticker.C:
+				}
+			}
+		}(job)
+	}
+}
+
+// Wait blocks until all jobs returned after the context of Start was canceled
+func (r *Runner) Wait() {
+	r.wg.Wait()
+}
+
+// run runs the job once if no other replica holds its lock
+func (r *Runner) run(ctx context.Context, job Job) {
+	logger := slog.With("job", job.Name)
+
+	if r.locker != nil && !job.Local {
+		unlock, ok, err := r.locker.TryLock(ctx, job.Name)
+		if err != nil {
+			logger.ErrorContext(ctx, "Failed to lock job", "error", err)
+			metrics.JobRuns.WithLabelValues(job.Name, "failed").Inc()
+			return
+		}
+		if !ok {
+			logger.DebugContext(ctx, "Job is running on another replica")
+			metrics.JobRuns.WithLabelValues(job.Name, "skipped").Inc()
+			return
+		}
+		defer unlock()
+	}
+
+	ctx, span := tracing.Start(ctx, "jobs."+job.Name)
+	start := time.Now()
+	err := job.Run(ctx)
+	tracing.End(span, err)
+	metrics.JobDuration.WithLabelValues(job.Name).Observe(time.Since(start).Seconds())
+
+	if err != nil {
+		logger.ErrorContext(ctx, "Job failed", "error", err)
+		metrics.JobRuns.WithLabelValues(job.Name, "failed").Inc()
+		return
+	}
+	metrics.JobRuns.WithLabelValues(job.Name, "ok").Inc()
+	metrics.JobLastSuccess.WithLabelValues(job.Name).SetToCurrentTime()
+}
@@ -0,0 +1,60 @@
+package logging
+
+import (
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+	"github.com/google/uuid"
+	"log/slog"
+	"time"
+)
+
+// RequestIDHeader carries the request ID, a valid ID sent by the client is kept
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength bounds IDs taken from the client, longer ones are replaced
+const maxRequestIDLength = 128
+
+// Middleware assigns every request an ID, stores it in the user context for the log messages
+// of service and repository, returns it in the response and logs the request when it is done.
+func Middleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+
+		id := utils.CopyString(c.Get(RequestIDHeader))
+		if id == "" || len(id) > maxRequestIDLength {
+			id = uuid.NewString()
+		}
+		c.Set(RequestIDHeader, id)
+
+		ctx := WithRequestID(c.UserContext(), id)
+		c.SetUserContext(ctx)
+		err := c.Next()
+
+		status := c.Response().StatusCode()
+		var fiberErr *fiber.Error
+		if errors.As(err, &fiberErr) {
+			status = fiberErr.Code
+		} else if err != nil {
+			status = fiber.StatusInternalServerError
+		}
+
+		level := slog.LevelInfo
+		if status >= fiber.StatusInternalServerError {
+			level = slog.LevelError
+		}
+		attrs := []slog.Attr{
+			slog.String("method", c.Method()),
+			slog.String("path", c.Path()),
+			slog.String("route", c.Route().Path),
+			slog.Int("status", status),
+			slog.Duration("latency", time.Since(start)),
+			slog.String("ip", c.IP()),
+		}
+		if err != nil {
+			attrs = append(attrs, slog.String("error", err.Error()))
+		}
+		slog.LogAttrs(ctx, level, "request", attrs...)
+		return err
+	}
+}
@@ -0,0 +1,88 @@
+// Package logging configures the structured logger and carries the request ID through contexts.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"sync/atomic"
+)
+
+type requestIDKey struct{}
+
+// audit enables the query audit log of the repositories
+var audit atomic.Bool
+
+// Setup installs a JSON or text logger with the given minimum level as default logger.
+// Messages of the log package end up in the same logger at level info.
+func Setup(w io.Writer, format string, level string, auditQueries bool) error {
+	var minLevel slog.Level
+	if err := minLevel.UnmarshalText([]byte(level)); err != nil {
+		return fmt.Errorf("unknown log level: %s", level)
+	}
+	options := &slog.HandlerOptions{Level: minLevel, ReplaceAttr: formatDuration}
+
+	var handler slog.Handler
+	switch format {
+	case "json":
+		handler = slog.NewJSONHandler(w, options)
+	case "text":
+		handler = slog.NewTextHandler(w, options)
+	default:
+		return fmt.Errorf("unknown log format: %s", format)
+	}
+
+	slog.SetDefault(slog.New(contextHandler{handler}))
+	SetAudit(auditQueries)
+	return nil
+}
+
+// formatDuration writes durations like 1.2ms instead of nanoseconds, as the health checks do
+func formatDuration(_ []string, attr slog.Attr) slog.Attr {
+	if attr.Value.Kind() == slog.KindDuration {
+		return slog.String(attr.Key, attr.Value.Duration().String())
+	}
+	return attr
+}
+
+// SetAudit switches the query audit log on or off
+func SetAudit(enabled bool) {
+	audit.Store(enabled)
+}
+
+// Audit reports whether searches log their queries
+func Audit() bool {
+	return audit.Load()
+}
+
+// WithRequestID returns a context whose log messages carry the request ID
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID returns the request ID of ctx, or "" outside of a request
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// contextHandler adds the request ID of the context to every record
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if id := RequestID(ctx); id != "" {
+		record.AddAttrs(slog.String("requestID", id))
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,41 @@
+package metrics
+
+import (
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+	"github.com/gofiber/fiber/v2/utils"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"strconv"
+	"time"
+)
+
+// Middleware observes the latency of every request, labeled with the route pattern
+// (e.g. /api/regions/:id) instead of the path to keep the number of series bounded.
+func Middleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+		err := c.Next()
+
+		status := c.Response().StatusCode()
+		route := c.Route().Path
+		var fiberErr *fiber.Error
+		if errors.As(err, &fiberErr) {
+			status = fiberErr.Code
+			if status == fiber.StatusNotFound {
+				route = "unmatched"
+			}
+		} else if err != nil {
+			status = fiber.StatusInternalServerError
+		}
+
+		// Fiber reuses the request buffers, label values must not alias them
+		RequestDuration.WithLabelValues(utils.CopyString(c.Method()), utils.CopyString(route), strconv.Itoa(status)).Observe(time.Since(start).Seconds())
+		return err
+	}
+}
+
+// Handler serves the metrics in the Prometheus text format
+func Handler() fiber.Handler {
+	return adaptor.HTTPHandler(promhttp.HandlerFor(Registry, promhttp.HandlerOpts{}))
+}
@@ -0,0 +1,81 @@
+// Package metrics collects the Prometheus metrics of the server in its own registry.
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+// Registry contains every metric exposed on /metrics
+var Registry = prometheus.NewRegistry()
+
+var (
+	// RequestDuration is observed by Middleware for every request
+	RequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "Latency of HTTP requests by route.",
+		Buckets: []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
+	}, []string{"method", "route", "status"})
+
+	// SearchRowsScanned counts the offers within region and time range of a search,
+	// before the optional filters are applied
+	SearchRowsScanned = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    "offer_search_rows_scanned",
+		Help:    "Offers within region and time range of a search, before the optional filters.",
+		Buckets: prometheus.ExponentialBuckets(1, 4, 12),
+	})
+
+	// OffersIngested counts the offers of POST /api/offers by outcome
+	OffersIngested = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "offers_ingested_total",
+		Help: "Offers written by POST /api/offers, by result (inserted, updated, skipped).",
+	}, []string{"result"})
+
+	// OffersRejected counts offers that failed validation
+	OffersRejected = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "offers_rejected_total",
+		Help: "Offers of POST /api/offers rejected by validation.",
+	})
+
+	// OffersDeleted counts deleted offers by the reason of the deletion
+	OffersDeleted = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "offers_deleted_total",
+		Help: "Deleted offers, by reason (expired, withdrawn, filtered).",
+	}, []string{"reason"})
+
+	// JobRuns counts the runs of background jobs by result
+	JobRuns = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "job_runs_total",
+		Help: "Runs of background jobs, by job and result (ok, failed, skipped while another replica holds the lock).",
+	}, []string{"job", "result"})
+
+	// JobDuration is observed for every run of a background job that got its lock
+	JobDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "job_duration_seconds",
+		Help:    "Duration of background job runs, by job.",
+		Buckets: prometheus.ExponentialBuckets(.01, 4, 10),
+	}, []string{"job"})
+
+	// JobLastSuccess is the time of the last successful run of a background job
+	JobLastSuccess = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "job_last_success_timestamp_seconds",
+		Help: "Unix time of the last successful run of a background job.",
+	}, []string{"job"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		RequestDuration,
+		SearchRowsScanned,
+		OffersIngested,
+		OffersRejected,
+		OffersDeleted,
+		JobRuns,
+		JobDuration,
+		JobLastSuccess,
+		offersStored,
+		pool,
+	)
+}
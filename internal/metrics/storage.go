@@ -0,0 +1,106 @@
+package metrics
+
+import (
+	"context"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+	"sync"
+	"time"
+)
+
+// countTimeout bounds counting the stored offers during a scrape
+const countTimeout = 5 * time.Second
+
+var offersStored = &offerCountCollector{
+	desc: prometheus.NewDesc("offers_stored", "Offers currently stored, estimated from the table statistics with PostgreSQL.", nil, nil),
+}
+
+// SetOfferCounter sets the function reporting the number of stored offers
+func SetOfferCounter(count func(ctx context.Context) (int, error)) {
+	offersStored.mu.Lock()
+	defer offersStored.mu.Unlock()
+	offersStored.count = count
+}
+
+// offerCountCollector asks the storage backend for the number of offers on every scrape,
+// so the count must be cheap and not scan the offers
+type offerCountCollector struct {
+	desc  *prometheus.Desc
+	mu    sync.Mutex
+	count func(ctx context.Context) (int, error)
+}
+
+func (c *offerCountCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c *offerCountCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mu.Lock()
+	count := c.count
+	c.mu.Unlock()
+	if count == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), countTimeout)
+	defer cancel()
+
+	stored, err := count(ctx)
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(c.desc, err)
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, float64(stored))
+}
+
+var pool = &poolCollector{
+	acquiredConns:        prometheus.NewDesc("pgxpool_acquired_conns", "Connections currently in use.", nil, nil),
+	idleConns:            prometheus.NewDesc("pgxpool_idle_conns", "Idle connections in the pool.", nil, nil),
+	totalConns:           prometheus.NewDesc("pgxpool_total_conns", "Connections in the pool, including those being established.", nil, nil),
+	maxConns:             prometheus.NewDesc("pgxpool_max_conns", "Maximum size of the pool.", nil, nil),
+	acquireCount:         prometheus.NewDesc("pgxpool_acquire_total", "Successful acquires from the pool.", nil, nil),
+	acquireDuration:      prometheus.NewDesc("pgxpool_acquire_duration_seconds_total", "Total time spent acquiring connections.", nil, nil),
+	emptyAcquireCount:    prometheus.NewDesc("pgxpool_empty_acquire_total", "Acquires that had to wait for a connection.", nil, nil),
+	canceledAcquireCount: prometheus.NewDesc("pgxpool_canceled_acquire_total", "Acquires canceled by their context.", nil, nil),
+}
+
+// SetPool sets the pool whose statistics are exported
+func SetPool(dbPool *pgxpool.Pool) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	pool.pool = dbPool
+}
+
+// poolCollector exports pgxpool.Stat on every scrape
+type poolCollector struct {
+	mu   sync.Mutex
+	pool *pgxpool.Pool
+
+	acquiredConns, idleConns, totalConns, maxConns                         *prometheus.Desc
+	acquireCount, acquireDuration, emptyAcquireCount, canceledAcquireCount *prometheus.Desc
+}
+
+func (c *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, desc := range []*prometheus.Desc{c.acquiredConns, c.idleConns, c.totalConns, c.maxConns, c.acquireCount, c.acquireDuration, c.emptyAcquireCount, c.canceledAcquireCount} {
+		ch <- desc
+	}
+}
+
+func (c *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mu.Lock()
+	dbPool := c.pool
+	c.mu.Unlock()
+	if dbPool == nil {
+		return
+	}
+
+	stat := dbPool.Stat()
+	ch <- prometheus.MustNewConstMetric(c.acquiredConns, prometheus.GaugeValue, float64(stat.AcquiredConns()))
+	ch <- prometheus.MustNewConstMetric(c.idleConns, prometheus.GaugeValue, float64(stat.IdleConns()))
+	ch <- prometheus.MustNewConstMetric(c.totalConns, prometheus.GaugeValue, float64(stat.TotalConns()))
+	ch <- prometheus.MustNewConstMetric(c.maxConns, prometheus.GaugeValue, float64(stat.MaxConns()))
+	ch <- prometheus.MustNewConstMetric(c.acquireCount, prometheus.CounterValue, float64(stat.AcquireCount()))
+	ch <- prometheus.MustNewConstMetric(c.acquireDuration, prometheus.CounterValue, stat.AcquireDuration().Seconds())
+	ch <- prometheus.MustNewConstMetric(c.emptyAcquireCount, prometheus.CounterValue, float64(stat.EmptyAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(c.canceledAcquireCount, prometheus.CounterValue, float64(stat.CanceledAcquireCount()))
+}
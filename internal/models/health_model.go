@@ -0,0 +1,15 @@
+package models
+
+// CheckResult is the outcome of one dependency check
+type CheckResult struct {
+	Status  string `json:"status"` // ok or failing
+	Latency string `json:"latency"`
+	Error   string `json:"error,omitempty"`
+}
+
+// HealthResponse is returned by /healthz and /readyz
+type HealthResponse struct {
+	Status string                 `json:"status"` // ok or unavailable
+	Uptime string                 `json:"uptime"`
+	Checks map[string]CheckResult `json:"checks"`
+}
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+)
+
+// OfferCursor identifies the last offer of a page for keyset pagination by its sort value and ID
+type OfferCursor struct {
+	SortOrder string `json:"s"`
+	Value     int64  `json:"v"`
+	ID        string `json:"i"`
+}
+
+// Encode returns the opaque representation handed out to clients
+func (c OfferCursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeOfferCursor parses a cursor created by OfferCursor.Encode
+func DecodeOfferCursor(value string) (OfferCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return OfferCursor{}, errors.New("malformed cursor")
+	}
+
+	var cursor OfferCursor
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.ID == "" {
+		return OfferCursor{}, errors.New("malformed cursor")
+	}
+
+	return cursor, nil
+}
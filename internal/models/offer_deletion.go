@@ -0,0 +1,21 @@
+package models
+
+// DeleteOffersResponse is the result of deleting offers by ID
+type DeleteOffersResponse struct {
+	Deleted  int      `json:"deleted"`
+	NotFound []string `json:"notFound"` // requested IDs that did not exist
+}
+
+// OfferDeletion selects the offers of a filtered delete with the filters of a search
+type OfferDeletion struct {
+	Filter     OfferFilterParams
+	AllRegions bool // no region was given, the offers of every region match
+	DryRun     bool // only count the matching offers
+}
+
+// OfferDeletionResponse is the result of a filtered delete
+type OfferDeletionResponse struct {
+	Matched int  `json:"matched"`
+	Deleted int  `json:"deleted"` // 0 in a dry run
+	DryRun  bool `json:"dryRun"`
+}
@@ -13,6 +13,32 @@ type Offer struct {
 	FreeKilometers       int    `json:"freeKilometers"`
 }
 
+// ConflictMode decides what happens to offers whose ID already exists
+type ConflictMode string
+
+const (
+	ConflictReject ConflictMode = "reject" // fail the whole batch
+	ConflictIgnore ConflictMode = "ignore" // keep the stored offer
+	ConflictUpdate ConflictMode = "update" // overwrite the stored offer
+)
+
+type InsertResult struct {
+	Inserted int `json:"inserted"`
+	Updated  int `json:"updated"`
+	Skipped  int `json:"skipped"`
+}
+
+type RejectedOffer struct {
+	Index  int      `json:"index"`
+	ID     string   `json:"ID"`
+	Errors []string `json:"errors"`
+}
+
+type CreateOffersResponse struct {
+	InsertResult
+	Rejected []RejectedOffer `json:"rejected"`
+}
+
 type OfferFilterParams struct {
 	RegionID              int
 	TimeRangeStart        int
@@ -29,6 +55,8 @@ type OfferFilterParams struct {
 	CarType               *string
 	OnlyVollkasko         *bool
 	MinFreeKilometer      *int
+	KeysetPagination      bool         // page through the results with cursors instead of page/pageSize
+	After                 *OfferCursor // last offer of the previous page in keyset mode
 }
 
 type ResponseOffer struct {
@@ -72,4 +100,5 @@ type OfferQueryResponse struct {
 	SeatsCount         []SeatsCount         `json:"seatsCount"`
 	FreeKilometerRange []FreeKilometerRange `json:"freeKilometerRange"`
 	VollkaskoCount     VollkaskoCount       `json:"vollkaskoCount"`
+	NextCursor         string               `json:"nextCursor,omitempty"`
 }
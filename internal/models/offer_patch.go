@@ -0,0 +1,46 @@
+package models
+
+// OfferPatch holds the fields of PATCH /api/offers/:id, fields that are nil keep their value.
+// The ID of an offer cannot be changed.
+type OfferPatch struct {
+	Data                 *string
+	MostSpecificRegionID *int
+	StartDate            *int64
+	EndDate              *int64
+	NumberSeats          *int
+	Price                *int
+	CarType              *string
+	OnlyVollkasko        *bool
+	FreeKilometers       *int
+}
+
+// Apply overwrites the fields of the offer that are set in the patch
+func (p OfferPatch) Apply(offer *Offer) {
+	if p.Data != nil {
+		offer.Data = *p.Data
+	}
+	if p.MostSpecificRegionID != nil {
+		offer.MostSpecificRegionID = *p.MostSpecificRegionID
+	}
+	if p.StartDate != nil {
+		offer.StartDate = *p.StartDate
+	}
+	if p.EndDate != nil {
+		offer.EndDate = *p.EndDate
+	}
+	if p.NumberSeats != nil {
+		offer.NumberSeats = *p.NumberSeats
+	}
+	if p.Price != nil {
+		offer.Price = *p.Price
+	}
+	if p.CarType != nil {
+		offer.CarType = *p.CarType
+	}
+	if p.OnlyVollkasko != nil {
+		offer.OnlyVollkasko = *p.OnlyVollkasko
+	}
+	if p.FreeKilometers != nil {
+		offer.FreeKilometers = *p.FreeKilometers
+	}
+}
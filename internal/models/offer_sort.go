@@ -0,0 +1,69 @@
+package models
+
+// SortKey is the offer attribute search results are ordered by
+type SortKey string
+
+const (
+	SortByPrice          SortKey = "price"
+	SortByFreeKilometers SortKey = "freeKilometers"
+	SortByNumberSeats    SortKey = "numberSeats"
+	SortByDuration       SortKey = "duration"
+	SortByStartDate      SortKey = "startDate"
+)
+
+// OfferSort is one of the supported sort orders. Offers with the same sort value are
+// always ordered by ascending ID, so every order is deterministic.
+type OfferSort struct {
+	Key        SortKey
+	Descending bool
+}
+
+// offerSorts whitelists the values accepted for the sortOrder parameter
+var offerSorts = map[string]OfferSort{
+	"price-asc":           {Key: SortByPrice},
+	"price-desc":          {Key: SortByPrice, Descending: true},
+	"freeKilometers-desc": {Key: SortByFreeKilometers, Descending: true},
+	"numberSeats-desc":    {Key: SortByNumberSeats, Descending: true},
+	"duration-asc":        {Key: SortByDuration},
+	"startDate-asc":       {Key: SortByStartDate},
+}
+
+// ParseOfferSort looks up a sortOrder value
+func ParseOfferSort(sortOrder string) (OfferSort, bool) {
+	sort, ok := offerSorts[sortOrder]
+	return sort, ok
+}
+
+// Value returns the attribute of the offer the results are sorted by
+func (s OfferSort) Value(offer Offer) int64 {
+	switch s.Key {
+	case SortByFreeKilometers:
+		return int64(offer.FreeKilometers)
+	case SortByNumberSeats:
+		return int64(offer.NumberSeats)
+	case SortByDuration:
+		return offer.EndDate - offer.StartDate
+	case SortByStartDate:
+		return offer.StartDate
+	default:
+		return int64(offer.Price)
+	}
+}
+
+// Before reports whether offer a is listed before offer b
+func (s OfferSort) Before(a, b Offer) bool {
+	valueA, valueB := s.Value(a), s.Value(b)
+	if valueA != valueB {
+		return (valueA < valueB) != s.Descending
+	}
+	return a.ID < b.ID
+}
+
+// After reports whether the offer is listed behind the cursor
+func (s OfferSort) After(offer Offer, cursor OfferCursor) bool {
+	value := s.Value(offer)
+	if value != cursor.Value {
+		return (value > cursor.Value) != s.Descending
+	}
+	return offer.ID > cursor.ID
+}
@@ -0,0 +1,30 @@
+package models
+
+import "server/internal/database"
+
+// RegionSummary identifies a region without its subregions
+type RegionSummary struct {
+	ID       int    `json:"id"`
+	Name     string `json:"name"`
+	ParentID *int   `json:"parentID"`
+}
+
+// RegionDetail is a region together with the path from the root and all its subregions
+type RegionDetail struct {
+	RegionSummary
+	Ancestors  []RegionSummary   `json:"ancestors"`
+	Subregions []database.Region `json:"subregions"`
+}
+
+// RegionDiff lists the changes between two versions of the region hierarchy
+type RegionDiff struct {
+	Added   []RegionSummary `json:"added"`
+	Renamed []RegionSummary `json:"renamed"`
+	Moved   []RegionSummary `json:"moved"`
+	Removed []RegionSummary `json:"removed"`
+}
+
+// Empty reports whether both versions are identical
+func (d RegionDiff) Empty() bool {
+	return len(d.Added)+len(d.Renamed)+len(d.Moved)+len(d.Removed) == 0
+}
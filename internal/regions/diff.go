@@ -0,0 +1,99 @@
+package regions
+
+import (
+	"fmt"
+	"server/internal/database"
+	"server/internal/models"
+	"sort"
+)
+
+// Flatten lists every region of the hierarchy with its parent.
+func Flatten(root database.Region) map[int]models.RegionSummary {
+	regions := make(map[int]models.RegionSummary)
+	var walk func(region database.Region, parentID *int)
+	walk = func(region database.Region, parentID *int) {
+		regions[region.ID] = models.RegionSummary{ID: region.ID, Name: region.Name, ParentID: parentID}
+		id := region.ID
+		for _, subregion := range region.Subregions {
+			walk(subregion, &id)
+		}
+	}
+	walk(root, nil)
+	return regions
+}
+
+// Build assembles the hierarchy from a flat list of regions. Subregions keep the order of the list.
+func Build(regions []models.RegionSummary) (database.Region, error) {
+	children := make(map[int][]models.RegionSummary)
+	var roots []models.RegionSummary
+	for _, region := range regions {
+		if region.ParentID == nil {
+			roots = append(roots, region)
+			continue
+		}
+		children[*region.ParentID] = append(children[*region.ParentID], region)
+	}
+	if len(roots) != 1 {
+		return database.Region{}, fmt.Errorf("expected exactly one root region, found %d", len(roots))
+	}
+
+	built := 0
+	var build func(region models.RegionSummary) database.Region
+	build = func(region models.RegionSummary) database.Region {
+		built++
+		result := database.Region{ID: region.ID, Name: region.Name}
+		for _, child := range children[region.ID] {
+			result.Subregions = append(result.Subregions, build(child))
+		}
+		return result
+	}
+	root := build(roots[0])
+	if built != len(regions) {
+		return database.Region{}, fmt.Errorf("%d regions are not connected to the root region", len(regions)-built)
+	}
+
+	return root, nil
+}
+
+// Diff compares the current regions with the next ones. A region that is renamed and
+// moved at the same time is listed in both Renamed and Moved.
+func Diff(current, next map[int]models.RegionSummary) models.RegionDiff {
+	diff := models.RegionDiff{
+		Added:   []models.RegionSummary{},
+		Renamed: []models.RegionSummary{},
+		Moved:   []models.RegionSummary{},
+		Removed: []models.RegionSummary{},
+	}
+
+	for id, region := range next {
+		old, exists := current[id]
+		if !exists {
+			diff.Added = append(diff.Added, region)
+			continue
+		}
+		if old.Name != region.Name {
+			diff.Renamed = append(diff.Renamed, region)
+		}
+		if !sameParent(old.ParentID, region.ParentID) {
+			diff.Moved = append(diff.Moved, region)
+		}
+	}
+	for id, region := range current {
+		if _, exists := next[id]; !exists {
+			diff.Removed = append(diff.Removed, region)
+		}
+	}
+
+	for _, regions := range [][]models.RegionSummary{diff.Added, diff.Renamed, diff.Moved, diff.Removed} {
+		sort.Slice(regions, func(i, j int) bool { return regions[i].ID < regions[j].ID })
+	}
+
+	return diff
+}
+
+func sameParent(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
@@ -0,0 +1,25 @@
+package regions
+
+import "sync/atomic"
+
+// Hierarchy holds the current index and allows replacing it while requests are served.
+type Hierarchy struct {
+	current atomic.Pointer[Index]
+}
+
+// NewHierarchy creates a hierarchy starting with the given index.
+func NewHierarchy(index *Index) *Hierarchy {
+	h := &Hierarchy{}
+	h.current.Store(index)
+	return h
+}
+
+// Index returns the index of the current region data.
+func (h *Hierarchy) Index() *Index {
+	return h.current.Load()
+}
+
+// Replace switches to the index of new region data.
+func (h *Hierarchy) Replace(index *Index) {
+	h.current.Store(index)
+}
@@ -0,0 +1,146 @@
+package regions
+
+import (
+	"server/internal/database"
+	"strings"
+)
+
+// Index numbers the region hierarchy in pre-order. Every subtree then covers a
+// contiguous range of positions, so "region A lies within region B" is a
+// constant-time range check instead of a walk over the tree.
+type Index struct {
+	positions map[int]int       // region id -> pre-order position
+	ids       []int             // pre-order position -> region id
+	last      []int             // pre-order position -> last position of its subtree
+	parents   []int             // pre-order position -> position of the parent, -1 for the root
+	regions   []database.Region // pre-order position -> region with its subregions
+	names     []string          // pre-order position -> lower-case name for searching
+}
+
+// NewIndex builds the index for the given root region and all its subregions.
+func NewIndex(root database.Region) *Index {
+	idx := &Index{positions: make(map[int]int)}
+	idx.number(root, -1)
+	return idx
+}
+
+// number assigns pre-order positions to the region and its subregions
+func (idx *Index) number(region database.Region, parent int) int {
+	position := len(idx.ids)
+	idx.positions[region.ID] = position
+	idx.ids = append(idx.ids, region.ID)
+	idx.last = append(idx.last, position)
+	idx.parents = append(idx.parents, parent)
+	idx.regions = append(idx.regions, region)
+	idx.names = append(idx.names, strings.ToLower(region.Name))
+
+	last := position
+	for _, subregion := range region.Subregions {
+		last = idx.number(subregion, position)
+	}
+	idx.last[position] = last
+
+	return last
+}
+
+// Len returns the number of regions in the index.
+func (idx *Index) Len() int {
+	return len(idx.ids)
+}
+
+// Position returns the pre-order position of a region.
+func (idx *Index) Position(regionID int) (int, bool) {
+	position, ok := idx.positions[regionID]
+	return position, ok
+}
+
+// Range returns the first and last position covered by the subtree of a region.
+func (idx *Index) Range(regionID int) (first int, last int, ok bool) {
+	position, ok := idx.positions[regionID]
+	if !ok {
+		return 0, 0, false
+	}
+	return position, idx.last[position], true
+}
+
+// Contains reports whether regionID is ancestorID itself or one of its subregions.
+func (idx *Index) Contains(ancestorID, regionID int) bool {
+	first, last, ok := idx.Range(ancestorID)
+	if !ok {
+		return false
+	}
+	position, ok := idx.positions[regionID]
+	return ok && position >= first && position <= last
+}
+
+// IsLeaf reports whether the region exists and has no subregions.
+func (idx *Index) IsLeaf(regionID int) bool {
+	position, ok := idx.positions[regionID]
+	return ok && idx.last[position] == position
+}
+
+// Root returns the root region with the whole hierarchy below it.
+func (idx *Index) Root() database.Region {
+	return idx.regions[0]
+}
+
+// Region returns a region with all its subregions.
+func (idx *Index) Region(regionID int) (database.Region, bool) {
+	position, ok := idx.positions[regionID]
+	if !ok {
+		return database.Region{}, false
+	}
+	return idx.regions[position], true
+}
+
+// Parent returns the ID of the parent region; the root has no parent.
+func (idx *Index) Parent(regionID int) (int, bool) {
+	position, ok := idx.positions[regionID]
+	if !ok || idx.parents[position] < 0 {
+		return 0, false
+	}
+	return idx.ids[idx.parents[position]], true
+}
+
+// Ancestors returns the regions above regionID, starting at the root.
+func (idx *Index) Ancestors(regionID int) []database.Region {
+	position, ok := idx.positions[regionID]
+	if !ok {
+		return nil
+	}
+
+	var ancestors []database.Region
+	for parent := idx.parents[position]; parent >= 0; parent = idx.parents[parent] {
+		ancestors = append(ancestors, idx.regions[parent])
+	}
+	for i, j := 0, len(ancestors)-1; i < j; i, j = i+1, j-1 {
+		ancestors[i], ancestors[j] = ancestors[j], ancestors[i]
+	}
+	return ancestors
+}
+
+// Search returns the IDs of the regions whose name contains the query, ignoring case.
+// Names starting with the query come first, otherwise the hierarchy order is kept.
+func (idx *Index) Search(query string, limit int) []int {
+	query = strings.ToLower(query)
+
+	var prefixed, contained []int
+	for position, name := range idx.names {
+		if strings.HasPrefix(name, query) {
+			prefixed = append(prefixed, position)
+		} else if strings.Contains(name, query) {
+			contained = append(contained, position)
+		}
+	}
+
+	positions := append(prefixed, contained...)
+	if limit > 0 && len(positions) > limit {
+		positions = positions[:limit]
+	}
+
+	ids := make([]int, 0, len(positions))
+	for _, position := range positions {
+		ids = append(ids, idx.ids[position])
+	}
+	return ids
+}
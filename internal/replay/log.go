@@ -0,0 +1,127 @@
+// Package replay replays evaluation logs of the Check24 platform against the API and compares
+// every search response with the result the platform expected.
+package replay
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Request types of the evaluation log
+const (
+	RequestPush            = "PUSH"
+	RequestRead            = "READ"
+	RequestCompetitorError = "COMPETITOR_ERROR"
+)
+
+// maxLineSize bounds one log line, PUSH entries contain whole batches of offers
+const maxLineSize = 256 * 1024 * 1024
+
+// Entry is one line of the log. Log is an object for PUSH and READ and a plain message for
+// COMPETITOR_ERROR, so it is decoded by the request type.
+type Entry struct {
+	Line        int             `json:"-"`
+	RequestType string          `json:"requestType"`
+	Timestamp   string          `json:"timestamp"`
+	Log         json.RawMessage `json:"log"`
+}
+
+// Request is the log of a PUSH or READ
+type Request struct {
+	ID             string          `json:"id"`
+	Duration       float64         `json:"duration"` // milliseconds the platform measured for the original run
+	WriteConfig    *WriteConfig    `json:"write_config"`
+	SearchConfig   *SearchConfig   `json:"search_config"`
+	ExpectedResult *Result         `json:"expected_result"`
+	ActualResult   *Result         `json:"actual_result"`
+	SearchError    json.RawMessage `json:"search_error"`
+	WriteError     json.RawMessage `json:"write_error"`
+}
+
+type WriteConfig struct {
+	ID     string     `json:"ID"`
+	Offers []LogOffer `json:"Offers"`
+}
+
+// LogOffer is an offer as the platform logs it, the data blob is not part of the log
+type LogOffer struct {
+	OfferID        string    `json:"OfferID"`
+	RegionID       int       `json:"RegionID"`
+	CarType        string    `json:"CarType"`
+	NumberDays     int       `json:"NumberDays"`
+	NumberSeats    int       `json:"NumberSeats"`
+	StartTimestamp time.Time `json:"StartTimestamp"`
+	EndTimestamp   time.Time `json:"EndTimestamp"`
+	Price          int       `json:"Price"`
+	HasVollkasko   bool      `json:"HasVollkasko"`
+	FreeKilometers int       `json:"FreeKilometers"`
+}
+
+type SearchConfig struct {
+	ID                string     `json:"ID"`
+	RegionID          int        `json:"RegionID"`
+	StartRange        time.Time  `json:"StartRange"`
+	EndRange          time.Time  `json:"EndRange"`
+	NumberDays        int        `json:"NumberDays"`
+	CarType           *string    `json:"CarType"`
+	OnlyVollkasko     *bool      `json:"OnlyVollkasko"`
+	MinFreeKilometer  *int       `json:"MinFreeKilometer"`
+	MinNumberSeats    *int       `json:"MinNumberSeats"`
+	MinPrice          *int       `json:"MinPrice"`
+	MaxPrice          *int       `json:"MaxPrice"`
+	Pagination        Pagination `json:"Pagination"`
+	Order             string     `json:"Order"`
+	PriceBucketWidth  int        `json:"PriceBucketWidth"`
+	FreeKmBucketWidth int        `json:"FreeKmBucketWidth"`
+}
+
+type Pagination struct {
+	Page     int `json:"Page"`
+	PageSize int `json:"PageSize"`
+}
+
+// ReadLog reads all entries of a log, one JSON object per line
+func ReadLog(r io.Reader) ([]Entry, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 1024*1024), maxLineSize)
+
+	var entries []Entry
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+		entry.Line = line
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// Request decodes the log of a PUSH or READ entry
+func (e Entry) Request() (Request, error) {
+	var request Request
+	if err := json.Unmarshal(e.Log, &request); err != nil {
+		return Request{}, fmt.Errorf("line %d: %v", e.Line, err)
+	}
+	switch {
+	case e.RequestType == RequestPush && request.WriteConfig == nil:
+		return Request{}, fmt.Errorf("line %d: PUSH without write_config", e.Line)
+	case e.RequestType == RequestRead && (request.SearchConfig == nil || request.ExpectedResult == nil):
+		return Request{}, fmt.Errorf("line %d: READ without search_config or expected_result", e.Line)
+	}
+	return request, nil
+}
+
+// WriteFailed reports whether the PUSH failed in the original run
+func (r Request) WriteFailed() bool {
+	return len(r.WriteError) > 0 && string(r.WriteError) != "null"
+}
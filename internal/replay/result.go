@@ -0,0 +1,179 @@
+package replay
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"reflect"
+	"server/internal/models"
+	"strconv"
+)
+
+// Facets of a search result in the order they are reported
+var Facets = []string{"Offers", "CarTypeCounts", "FreeKilometerRanges", "PriceRanges", "SeatsCounts", "VollkaskoCount"}
+
+// Result is a search result in the format of the evaluation log
+type Result struct {
+	Offers              []ResultOffer  `json:"Offers"`
+	CarTypeCounts       map[string]int `json:"CarTypeCounts"`
+	FreeKilometerRanges []Range        `json:"FreeKilometerRanges"`
+	PriceRanges         []Range        `json:"PriceRanges"`
+	SeatsCounts         map[string]int `json:"SeatsCounts"`
+	VollkaskoCount      VollkaskoCount `json:"VollkaskoCount"`
+}
+
+type ResultOffer struct {
+	OfferID       string `json:"OfferID"`
+	IsDataCorrect bool   `json:"IsDataCorrect"`
+}
+
+type Range struct {
+	Start int `json:"Start"`
+	End   int `json:"End"`
+	Count int `json:"Count"`
+}
+
+type VollkaskoCount struct {
+	TrueCount  int `json:"TrueCount"`
+	FalseCount int `json:"FalseCount"`
+}
+
+// Mismatch is a facet whose response differs from the expected result
+type Mismatch struct {
+	Facet    string
+	Expected string
+	Actual   string
+}
+
+// OfferData is the data blob pushed for an offer. The log does not contain the original blob,
+// so it is derived from the ID and a search checks that it comes back unchanged.
+func OfferData(id string) string {
+	return base64.StdEncoding.EncodeToString([]byte(id))
+}
+
+// RequestOffers converts the logged offers into the body of POST /api/offers
+func (w WriteConfig) RequestOffers() []models.Offer {
+	offers := make([]models.Offer, 0, len(w.Offers))
+	for _, offer := range w.Offers {
+		offers = append(offers, models.Offer{
+			ID:                   offer.OfferID,
+			Data:                 OfferData(offer.OfferID),
+			MostSpecificRegionID: offer.RegionID,
+			StartDate:            offer.StartTimestamp.UnixMilli(),
+			EndDate:              offer.EndTimestamp.UnixMilli(),
+			NumberSeats:          offer.NumberSeats,
+			Price:                offer.Price,
+			CarType:              offer.CarType,
+			OnlyVollkasko:        offer.HasVollkasko,
+			FreeKilometers:       offer.FreeKilometers,
+		})
+	}
+	return offers
+}
+
+// Query converts the search into the query parameters of GET /api/offers
+func (s SearchConfig) Query() url.Values {
+	query := url.Values{}
+	query.Set("regionID", strconv.Itoa(s.RegionID))
+	query.Set("timeRangeStart", strconv.FormatInt(s.StartRange.UnixMilli(), 10))
+	query.Set("timeRangeEnd", strconv.FormatInt(s.EndRange.UnixMilli(), 10))
+	query.Set("numberDays", strconv.Itoa(s.NumberDays))
+	query.Set("sortOrder", s.Order)
+	query.Set("page", strconv.Itoa(s.Pagination.Page))
+	query.Set("pageSize", strconv.Itoa(s.Pagination.PageSize))
+	query.Set("priceRangeWidth", strconv.Itoa(s.PriceBucketWidth))
+	query.Set("minFreeKilometerWidth", strconv.Itoa(s.FreeKmBucketWidth))
+
+	if s.CarType != nil {
+		query.Set("carType", *s.CarType)
+	}
+	if s.OnlyVollkasko != nil {
+		query.Set("onlyVollkasko", strconv.FormatBool(*s.OnlyVollkasko))
+	}
+	for name, value := range map[string]*int{
+		"minFreeKilometer": s.MinFreeKilometer,
+		"minNumberSeats":   s.MinNumberSeats,
+		"minPrice":         s.MinPrice,
+		"maxPrice":         s.MaxPrice,
+	} {
+		if value != nil {
+			query.Set(name, strconv.Itoa(*value))
+		}
+	}
+	return query
+}
+
+// NewResult converts a response of GET /api/offers into the format of the log
+func NewResult(response models.OfferQueryResponse) Result {
+	result := Result{
+		Offers: make([]ResultOffer, 0, len(response.Offers)),
+		CarTypeCounts: map[string]int{
+			"small":  response.CarTypeCounts.Small,
+			"sports": response.CarTypeCounts.Sports,
+			"luxury": response.CarTypeCounts.Luxury,
+			"family": response.CarTypeCounts.Family,
+		},
+		FreeKilometerRanges: make([]Range, 0, len(response.FreeKilometerRange)),
+		PriceRanges:         make([]Range, 0, len(response.PriceRanges)),
+		SeatsCounts:         make(map[string]int, len(response.SeatsCount)),
+		VollkaskoCount: VollkaskoCount{
+			TrueCount:  response.VollkaskoCount.TrueCount,
+			FalseCount: response.VollkaskoCount.FalseCount,
+		},
+	}
+	for _, offer := range response.Offers {
+		result.Offers = append(result.Offers, ResultOffer{OfferID: offer.ID, IsDataCorrect: offer.Data == OfferData(offer.ID)})
+	}
+	for _, bucket := range response.FreeKilometerRange {
+		result.FreeKilometerRanges = append(result.FreeKilometerRanges, Range{Start: bucket.Start, End: bucket.End, Count: bucket.Count})
+	}
+	for _, bucket := range response.PriceRanges {
+		result.PriceRanges = append(result.PriceRanges, Range{Start: bucket.Start, End: bucket.End, Count: bucket.Count})
+	}
+	for _, seats := range response.SeatsCount {
+		result.SeatsCounts[strconv.Itoa(seats.NumberSeats)] = seats.Count
+	}
+	return result
+}
+
+// Diff compares a response with the expected result facet by facet
+func Diff(expected, actual Result) []Mismatch {
+	var mismatches []Mismatch
+	compare := func(facet string, expected, actual interface{}) {
+		if !reflect.DeepEqual(expected, actual) {
+			mismatches = append(mismatches, Mismatch{Facet: facet, Expected: render(expected), Actual: render(actual)})
+		}
+	}
+
+	compare("Offers", nonNil(expected.Offers), nonNil(actual.Offers))
+	compare("CarTypeCounts", nonNilMap(expected.CarTypeCounts), nonNilMap(actual.CarTypeCounts))
+	compare("FreeKilometerRanges", nonNil(expected.FreeKilometerRanges), nonNil(actual.FreeKilometerRanges))
+	compare("PriceRanges", nonNil(expected.PriceRanges), nonNil(actual.PriceRanges))
+	compare("SeatsCounts", nonNilMap(expected.SeatsCounts), nonNilMap(actual.SeatsCounts))
+	compare("VollkaskoCount", expected.VollkaskoCount, actual.VollkaskoCount)
+	return mismatches
+}
+
+// nonNil makes an empty list equal to a missing one
+func nonNil[T any](list []T) []T {
+	if list == nil {
+		return []T{}
+	}
+	return list
+}
+
+func nonNilMap(counts map[string]int) map[string]int {
+	if counts == nil {
+		return map[string]int{}
+	}
+	return counts
+}
+
+func render(value interface{}) string {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(encoded)
+}
@@ -0,0 +1,220 @@
+package replay
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"io"
+	"net/http"
+	"server/internal/models"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Client sends requests to the API, an *http.Client or an AppClient
+type Client interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// AppClient sends requests to an app in the same process, without network
+type AppClient struct {
+	App *fiber.App
+}
+
+func (c AppClient) Do(req *http.Request) (*http.Response, error) {
+	return c.App.Test(req, -1)
+}
+
+// Failure is a replayed request that failed or returned a different result
+type Failure struct {
+	Line        int
+	RequestType string
+	ID          string
+	Error       string     // set when the request itself failed
+	Mismatches  []Mismatch // facets differing from the expected result
+}
+
+// Report summarizes a replay
+type Report struct {
+	Pushes     int
+	Reads      int
+	Skipped    int            // entries that are no requests, e.g. COMPETITOR_ERROR, and PUSHes that failed originally
+	Mismatches map[string]int // mismatching READs per facet
+	Failures   []Failure
+	Latencies  map[string][]time.Duration // per request type
+	Recorded   map[string][]time.Duration // latencies the platform logged for the original run
+}
+
+// Failed reports whether any request failed or mismatched
+func (r *Report) Failed() bool {
+	return len(r.Failures) > 0
+}
+
+// Run replays the entries in order against baseURL, e.g. http://localhost:80 or "" for an AppClient.
+// Every search sees exactly the offers pushed before it, so the entries are sent one by one.
+// PUSHes with a write_error failed in the original run and are skipped, their outcome is unknown.
+func Run(ctx context.Context, client Client, baseURL string, entries []Entry) (*Report, error) {
+	report := &Report{
+		Mismatches: make(map[string]int),
+		Latencies:  make(map[string][]time.Duration),
+		Recorded:   make(map[string][]time.Duration),
+	}
+	baseURL = strings.TrimSuffix(baseURL, "/")
+
+	for _, entry := range entries {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+		if entry.RequestType != RequestPush && entry.RequestType != RequestRead {
+			report.Skipped++
+			continue
+		}
+
+		request, err := entry.Request()
+		if err != nil {
+			return report, err
+		}
+		if entry.RequestType == RequestPush && request.WriteFailed() {
+			report.Skipped++
+			continue
+		}
+		report.Recorded[entry.RequestType] = append(report.Recorded[entry.RequestType], time.Duration(request.Duration*float64(time.Millisecond)))
+
+		failure := Failure{Line: entry.Line, RequestType: entry.RequestType, ID: request.ID}
+		var latency time.Duration
+		if entry.RequestType == RequestPush {
+			report.Pushes++
+			latency, err = push(ctx, client, baseURL, *request.WriteConfig)
+		} else {
+			report.Reads++
+			var result Result
+			result, latency, err = search(ctx, client, baseURL, *request.SearchConfig)
+			if err == nil {
+				failure.Mismatches = Diff(*request.ExpectedResult, result)
+				for _, mismatch := range failure.Mismatches {
+					report.Mismatches[mismatch.Facet]++
+				}
+			}
+		}
+		report.Latencies[entry.RequestType] = append(report.Latencies[entry.RequestType], latency)
+
+		if err != nil {
+			failure.Error = err.Error()
+		}
+		if err != nil || len(failure.Mismatches) > 0 {
+			report.Failures = append(report.Failures, failure)
+		}
+	}
+	return report, nil
+}
+
+// push sends the offers of a PUSH
+func push(ctx context.Context, client Client, baseURL string, config WriteConfig) (time.Duration, error) {
+	body, err := json.Marshal(map[string][]models.Offer{"offers": config.RequestOffers()})
+	if err != nil {
+		return 0, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, baseURL+"/api/offers", bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	_, latency, err := send(client, req)
+	return latency, err
+}
+
+// search sends the query of a READ and converts the response into the format of the log
+func search(ctx context.Context, client Client, baseURL string, config SearchConfig) (Result, time.Duration, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, baseURL+"/api/offers?"+config.Query().Encode(), nil)
+	if err != nil {
+		return Result{}, 0, err
+	}
+
+	body, latency, err := send(client, req)
+	if err != nil {
+		return Result{}, latency, err
+	}
+	var response models.OfferQueryResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return Result{}, latency, fmt.Errorf("cannot parse response: %v", err)
+	}
+	return NewResult(response), latency, nil
+}
+
+// send measures the request until the whole body was read, any status but 200 is an error
+func send(client Client, req *http.Request) ([]byte, time.Duration, error) {
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, time.Since(start), err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	latency := time.Since(start)
+	if err != nil {
+		return nil, latency, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, latency, fmt.Errorf("status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return body, latency, nil
+}
+
+// Percentile returns the nearest-rank percentile p (0-100) of the latencies
+func Percentile(latencies []time.Duration, p float64) time.Duration {
+	if len(latencies) == 0 {
+		return 0
+	}
+	sorted := append([]time.Duration(nil), latencies...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	rank := int(p/100*float64(len(sorted))+0.999999) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	if rank >= len(sorted) {
+		rank = len(sorted) - 1
+	}
+	return sorted[rank]
+}
+
+// Write prints the summary, the failures are listed with verbose only
+func (r *Report) Write(w io.Writer, verbose bool) {
+	fmt.Fprintf(w, "Replayed %d PUSH and %d READ requests, skipped %d entries\n", r.Pushes, r.Reads, r.Skipped)
+
+	fmt.Fprintf(w, "\nFailed requests: %d\n", len(r.Failures))
+	for _, facet := range Facets {
+		fmt.Fprintf(w, "  %-20s %d\n", facet, r.Mismatches[facet])
+	}
+
+	fmt.Fprintf(w, "\n%-16s %6s %10s %10s %10s %10s %10s\n", "Latency", "count", "p50", "p90", "p95", "p99", "max")
+	for _, requestType := range []string{RequestPush, RequestRead} {
+		for _, run := range []struct {
+			name      string
+			latencies []time.Duration
+		}{{"replay", r.Latencies[requestType]}, {"recorded", r.Recorded[requestType]}} {
+			fmt.Fprintf(w, "%-16s %6d", requestType+" "+run.name, len(run.latencies))
+			for _, p := range []float64{50, 90, 95, 99, 100} {
+				fmt.Fprintf(w, " %10s", Percentile(run.latencies, p).Round(time.Microsecond))
+			}
+			fmt.Fprintln(w)
+		}
+	}
+
+	if !verbose {
+		return
+	}
+	for _, failure := range r.Failures {
+		fmt.Fprintf(w, "\nLine %d, %s %s\n", failure.Line, failure.RequestType, failure.ID)
+		if failure.Error != "" {
+			fmt.Fprintf(w, "  error: %s\n", failure.Error)
+		}
+		for _, mismatch := range failure.Mismatches {
+			fmt.Fprintf(w, "  %s\n    expected: %s\n    actual:   %s\n", mismatch.Facet, mismatch.Expected, mismatch.Actual)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"server/internal/models"
+	"sort"
+)
+
+// offerFacets accumulates the facet counts of a search, either from the rows of the
+// facet query or offer by offer.
+type offerFacets struct {
+	priceRanges    map[int]int
+	carTypes       models.CarTypeCounts
+	numberSeats    map[int]int
+	freeKilometers map[int]int
+	vollkasko      models.VollkaskoCount
+	scanned        int
+}
+
+func newOfferFacets() *offerFacets {
+	return &offerFacets{
+		priceRanges:    make(map[int]int),
+		numberSeats:    make(map[int]int),
+		freeKilometers: make(map[int]int),
+	}
+}
+
+// add counts offers for the bucket (or label for car types) of a facet
+func (f *offerFacets) add(facet string, bucket int, label string, count int) {
+	switch facet {
+	case facetPrice:
+		f.priceRanges[bucket] += count
+	case facetCarType:
+		switch label {
+		case "small":
+			f.carTypes.Small += count
+		case "sports":
+			f.carTypes.Sports += count
+		case "luxury":
+			f.carTypes.Luxury += count
+		case "family":
+			f.carTypes.Family += count
+		}
+	case facetNumberSeats:
+		f.numberSeats[bucket] += count
+	case facetFreeKilometers:
+		f.freeKilometers[bucket] += count
+	case facetVollkasko:
+		if bucket == 1 {
+			f.vollkasko.TrueCount += count
+		} else {
+			f.vollkasko.FalseCount += count
+		}
+	case facetScanned:
+		f.scanned += count
+	}
+}
+
+// addOffer counts a single offer for every facet whose filters it satisfies
+func (f *offerFacets) addOffer(params models.OfferFilterParams, offer models.Offer) {
+	if matchesFilters(params, offer, filterPrice) {
+		f.add(facetPrice, (offer.Price/params.PriceRangeWidth)*params.PriceRangeWidth, "", 1)
+	}
+	if matchesFilters(params, offer, filterCarType) {
+		f.add(facetCarType, 0, offer.CarType, 1)
+	}
+	if matchesFilters(params, offer, filterNumberSeats) {
+		f.add(facetNumberSeats, offer.NumberSeats, "", 1)
+	}
+	if matchesFilters(params, offer, filterFreeKilometers) {
+		f.add(facetFreeKilometers, (offer.FreeKilometers/params.MinFreeKilometerWidth)*params.MinFreeKilometerWidth, "", 1)
+	}
+	if matchesFilters(params, offer, filterVollkasko) {
+		vollkasko := 0
+		if offer.OnlyVollkasko {
+			vollkasko = 1
+		}
+		f.add(facetVollkasko, vollkasko, "", 1)
+	}
+}
+
+// response builds the search response with all facets sorted ascending by their bucket
+func (f *offerFacets) response(params models.OfferFilterParams, offers []models.ResponseOffer) models.OfferQueryResponse {
+	priceRanges := make([]models.PriceRange, 0, len(f.priceRanges))
+	for _, start := range sortedKeys(f.priceRanges) {
+		priceRanges = append(priceRanges, models.PriceRange{Start: start, End: start + params.PriceRangeWidth, Count: f.priceRanges[start]})
+	}
+
+	seatsCount := make([]models.SeatsCount, 0, len(f.numberSeats))
+	for _, numberSeats := range sortedKeys(f.numberSeats) {
+		seatsCount = append(seatsCount, models.SeatsCount{NumberSeats: numberSeats, Count: f.numberSeats[numberSeats]})
+	}
+
+	freeKilometerRanges := make([]models.FreeKilometerRange, 0, len(f.freeKilometers))
+	for _, start := range sortedKeys(f.freeKilometers) {
+		freeKilometerRanges = append(freeKilometerRanges, models.FreeKilometerRange{Start: start, End: start + params.MinFreeKilometerWidth, Count: f.freeKilometers[start]})
+	}
+
+	return models.OfferQueryResponse{
+		Offers:             offers,
+		PriceRanges:        priceRanges,
+		CarTypeCounts:      f.carTypes,
+		SeatsCount:         seatsCount,
+		FreeKilometerRange: freeKilometerRanges,
+		VollkaskoCount:     f.vollkasko,
+	}
+}
+
+// matchesFilters evaluates the optional filters for an offer, leaving out the excluded one.
+// It mirrors offerQuery.filters for backends that do not run SQL.
+func matchesFilters(params models.OfferFilterParams, offer models.Offer, except offerFilter) bool {
+	if params.MinNumberSeats != nil && except != filterNumberSeats && offer.NumberSeats < *params.MinNumberSeats {
+		return false
+	}
+	if except != filterPrice {
+		if params.MinPrice != nil && offer.Price < *params.MinPrice {
+			return false
+		}
+		if params.MaxPrice != nil && offer.Price >= *params.MaxPrice {
+			return false
+		}
+	}
+	if params.CarType != nil && except != filterCarType && offer.CarType != *params.CarType {
+		return false
+	}
+	if params.OnlyVollkasko != nil && except != filterVollkasko && offer.OnlyVollkasko != *params.OnlyVollkasko {
+		return false
+	}
+	if params.MinFreeKilometer != nil && except != filterFreeKilometers && offer.FreeKilometers < *params.MinFreeKilometer {
+		return false
+	}
+	return true
+}
+
+func sortedKeys(counts map[int]int) []int {
+	keys := make([]int, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	sort.Ints(keys)
+	return keys
+}
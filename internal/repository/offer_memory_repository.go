@@ -0,0 +1,351 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"go.opentelemetry.io/otel/attribute"
+	"log/slog"
+	"server/internal/logging"
+	"server/internal/metrics"
+	"server/internal/models"
+	"server/internal/regions"
+	"server/internal/tracing"
+	"sort"
+	"sync"
+	"time"
+)
+
+type offerMemoryRepository struct {
+	mu      sync.RWMutex
+	regions *regions.Index
+	buckets [][]models.Offer // offers per region, indexed by pre-order position
+	ids     map[string]int   // offer id -> bucket position
+
+	regionVersion int64 // incremented by ReplaceRegions
+}
+
+// NewOfferMemoryRepository erstellt ein Repository, das alle Angebote im Arbeitsspeicher hält.
+func NewOfferMemoryRepository(index *regions.Index) OfferRepository {
+	return &offerMemoryRepository{
+		regions: index,
+		buckets: make([][]models.Offer, index.Len()),
+		ids:     make(map[string]int),
+	}
+}
+
+// CreateOffers fügt die Angebote hinzu. Wie beim INSERT in PostgreSQL wird der ganze Batch
+// abgelehnt, sobald ein Angebot ungültig ist oder eine ID im Modus reject bereits existiert
+// oder im Batch mehrfach vorkommt.
+func (r *offerMemoryRepository) CreateOffers(ctx context.Context, offers []models.Offer, mode models.ConflictMode) (models.InsertResult, error) {
+	var result models.InsertResult
+	if len(offers) == 0 {
+		return result, nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if unknown := r.unknownRegions(offers); len(unknown) > 0 {
+		return models.InsertResult{}, &UnknownRegionsError{RegionIDs: unknown}
+	}
+
+	positions := make([]int, len(offers))
+	batch := make(map[string]bool, len(offers))
+	for i, offer := range offers {
+		position, _ := r.regions.Position(offer.MostSpecificRegionID)
+		if _, exists := r.ids[offer.ID]; (exists || batch[offer.ID]) && mode == models.ConflictReject {
+			return models.InsertResult{}, fmt.Errorf("%w: %s", ErrDuplicateOffer, offer.ID)
+		}
+		positions[i] = position
+		batch[offer.ID] = true
+	}
+
+	for i, offer := range offers {
+		if old, exists := r.ids[offer.ID]; exists {
+			if mode == models.ConflictIgnore {
+				result.Skipped++
+				continue
+			}
+			r.remove(old, offer.ID)
+			result.Updated++
+		} else {
+			result.Inserted++
+		}
+
+		r.buckets[positions[i]] = append(r.buckets[positions[i]], offer)
+		r.ids[offer.ID] = positions[i]
+	}
+
+	return result, nil
+}
+
+// unknownRegions returns the sorted regions of the offers that are missing in the index
+func (r *offerMemoryRepository) unknownRegions(offers []models.Offer) []int {
+	seen := make(map[int]bool)
+	var unknown []int
+	for _, offer := range offers {
+		if _, ok := r.regions.Position(offer.MostSpecificRegionID); !ok && !seen[offer.MostSpecificRegionID] {
+			seen[offer.MostSpecificRegionID] = true
+			unknown = append(unknown, offer.MostSpecificRegionID)
+		}
+	}
+	sort.Ints(unknown)
+	return unknown
+}
+
+// remove deletes an offer from its bucket
+func (r *offerMemoryRepository) remove(position int, id string) {
+	if i := r.find(position, id); i >= 0 {
+		bucket := r.buckets[position]
+		bucket[i] = bucket[len(bucket)-1]
+		r.buckets[position] = bucket[:len(bucket)-1]
+	}
+	delete(r.ids, id)
+}
+
+// find returns the index of the offer in its bucket
+func (r *offerMemoryRepository) find(position int, id string) int {
+	for i := range r.buckets[position] {
+		if r.buckets[position][i].ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// GetOffer liefert ein einzelnes Angebot.
+func (r *offerMemoryRepository) GetOffer(ctx context.Context, id string) (models.Offer, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	position, ok := r.ids[id]
+	if !ok {
+		return models.Offer{}, ErrOfferNotFound
+	}
+	return r.buckets[position][r.find(position, id)], nil
+}
+
+// UpdateOffer ändert das Angebot mit update und verschiebt es, falls sich die Region geändert hat.
+// Gibt update einen Fehler zurück, bleibt das Angebot unverändert.
+func (r *offerMemoryRepository) UpdateOffer(ctx context.Context, id string, update func(offer *models.Offer) error) (models.Offer, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	position, ok := r.ids[id]
+	if !ok {
+		return models.Offer{}, ErrOfferNotFound
+	}
+	index := r.find(position, id)
+	offer := r.buckets[position][index]
+	if err := update(&offer); err != nil {
+		return models.Offer{}, err
+	}
+
+	target, ok := r.regions.Position(offer.MostSpecificRegionID)
+	if !ok {
+		return models.Offer{}, &UnknownRegionsError{RegionIDs: []int{offer.MostSpecificRegionID}}
+	}
+	if target == position {
+		r.buckets[position][index] = offer
+		return offer, nil
+	}
+	r.remove(position, id)
+	r.buckets[target] = append(r.buckets[target], offer)
+	r.ids[id] = target
+	return offer, nil
+}
+
+// DeleteOffers löscht die Angebote mit den angegebenen IDs und liefert die IDs der gelöschten.
+func (r *offerMemoryRepository) DeleteOffers(ctx context.Context, ids []string) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	deleted := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if position, ok := r.ids[id]; ok {
+			r.remove(position, id)
+			deleted = append(deleted, id)
+		}
+	}
+	return deleted, nil
+}
+
+// DeleteMatchingOffers löscht alle Angebote, die die Filter der Suche erfüllen, und liefert deren Anzahl.
+// Mit dryRun werden sie nur gezählt.
+func (r *offerMemoryRepository) DeleteMatchingOffers(ctx context.Context, params models.OfferFilterParams, dryRun bool) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	first, last, ok := r.regions.Range(params.RegionID)
+	if !ok {
+		return 0, nil
+	}
+
+	matched := 0
+	for position := first; position <= last; position++ {
+		kept := r.buckets[position][:0]
+		for _, offer := range r.buckets[position] {
+			if !inSearchWindow(params, offer) || !matchesFilters(params, offer, filterNone) {
+				kept = append(kept, offer)
+				continue
+			}
+			matched++
+			if dryRun {
+				kept = append(kept, offer)
+				continue
+			}
+			delete(r.ids, offer.ID)
+		}
+		r.buckets[position] = kept
+	}
+
+	return matched, nil
+}
+
+// DeleteOldOffers löscht veraltete Angebote aus dem Speicher.
+func (r *offerMemoryRepository) DeleteOldOffers(ctx context.Context) (int, error) {
+	now := time.Now().UnixMilli()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	deleted := 0
+	for position, bucket := range r.buckets {
+		kept := bucket[:0]
+		for _, offer := range bucket {
+			if offer.EndDate < now {
+				delete(r.ids, offer.ID)
+				deleted++
+				continue
+			}
+			kept = append(kept, offer)
+		}
+		r.buckets[position] = kept
+	}
+
+	return deleted, nil
+}
+
+// DeleteExpiredOffers löscht höchstens limit Angebote, die vor before (Unix-Millisekunden) enden.
+func (r *offerMemoryRepository) DeleteExpiredOffers(ctx context.Context, before int64, limit int) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	deleted := 0
+	for position, bucket := range r.buckets {
+		if deleted == limit {
+			break
+		}
+		kept := bucket[:0]
+		for _, offer := range bucket {
+			if offer.EndDate < before && deleted < limit {
+				delete(r.ids, offer.ID)
+				deleted++
+				continue
+			}
+			kept = append(kept, offer)
+		}
+		r.buckets[position] = kept
+	}
+
+	return deleted, nil
+}
+
+// CountOffers liefert die Anzahl der gespeicherten Angebote.
+func (r *offerMemoryRepository) CountOffers(ctx context.Context) (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return len(r.ids), nil
+}
+
+// EstimateOffers liefert die genaue Anzahl, sie ist im Speicher ohne Aufwand bekannt.
+func (r *offerMemoryRepository) EstimateOffers(ctx context.Context) (int, error) {
+	return r.CountOffers(ctx)
+}
+
+// inSearchWindow reports whether the offer lies in the time window of the search and lasts at least numberDays
+func inSearchWindow(params models.OfferFilterParams, offer models.Offer) bool {
+	return offer.StartDate >= int64(params.TimeRangeStart) &&
+		offer.EndDate <= int64(params.TimeRangeEnd) &&
+		offer.EndDate-offer.StartDate >= int64(params.NumberDays)*24*3600*1000
+}
+
+// GetOffers liefert die gewünschte Seite der Angebote und alle Aggregationen über die gesamte Treffermenge.
+// Die Subregionen einer Region belegen einen zusammenhängenden Bereich von Buckets.
+func (r *offerMemoryRepository) GetOffers(ctx context.Context, params models.OfferFilterParams) (models.OfferQueryResponse, error) {
+	plan, err := planOfferQuery(params)
+	if err != nil {
+		return models.OfferQueryResponse{}, err
+	}
+
+	ctx, span := tracing.Start(ctx, "offerMemoryRepository.GetOffers")
+	defer span.End()
+	begin := time.Now()
+
+	facets := newOfferFacets()
+
+	r.mu.RLock()
+	first, last, ok := r.regions.Range(params.RegionID)
+	if !ok {
+		r.mu.RUnlock()
+		return facets.response(params, []models.ResponseOffer{}), nil
+	}
+
+	var matches []models.Offer
+	for _, bucket := range r.buckets[first : last+1] {
+		for _, offer := range bucket {
+			if !inSearchWindow(params, offer) {
+				continue
+			}
+
+			facets.scanned++
+			facets.addOffer(params, offer)
+			if matchesFilters(params, offer, filterNone) {
+				matches = append(matches, offer)
+			}
+		}
+	}
+	r.mu.RUnlock()
+	metrics.SearchRowsScanned.Observe(float64(facets.scanned))
+	span.SetAttributes(attribute.Int("offers.scanned", facets.scanned), attribute.Int("offers.matched", len(matches)))
+
+	sort.Slice(matches, func(i, j int) bool {
+		return plan.sort.Before(matches[i], matches[j])
+	})
+
+	// Apply pagination, keyset pages start behind the cursor instead of an offset
+	start := params.Page * params.PageSize
+	if params.KeysetPagination {
+		start = 0
+		if after := params.After; after != nil {
+			start = sort.Search(len(matches), func(i int) bool {
+				return plan.sort.After(matches[i], *after)
+			})
+		}
+	}
+
+	offers := []models.ResponseOffer{}
+	end := start + params.PageSize
+	if start >= 0 && start < len(matches) && end > start {
+		if end > len(matches) {
+			end = len(matches)
+		}
+		offers = make([]models.ResponseOffer, 0, end-start)
+		for _, offer := range matches[start:end] {
+			offers = append(offers, models.ResponseOffer{ID: offer.ID, Data: offer.Data})
+		}
+	}
+
+	response := facets.response(params, offers)
+	if len(offers) > 0 {
+		response.NextCursor = plan.nextCursor(len(offers), plan.sort.Value(matches[end-1]), matches[end-1].ID)
+	}
+
+	// There is no SQL to render, the audit log shows the search and what it touched
+	if logging.Audit() {
+		slog.InfoContext(ctx, "Query audit", "statement", "memory", "params", params, "scanned", facets.scanned, "rows", len(matches), "duration", time.Since(begin))
+	}
+
+	return response, nil
+}
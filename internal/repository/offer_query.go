@@ -0,0 +1,230 @@
+package repository
+
+import (
+	"fmt"
+	"server/internal/models"
+	"strconv"
+	"strings"
+)
+
+// offerFilter identifies one of the optional search filters. The offers of a page have to
+// satisfy all filters, while every facet is aggregated over all filters except its own.
+type offerFilter int
+
+const (
+	filterNone offerFilter = iota
+	filterNumberSeats
+	filterPrice
+	filterCarType
+	filterVollkasko
+	filterFreeKilometers
+)
+
+// Facet names as returned by the facet query
+const (
+	facetPrice          = "price"
+	facetCarType        = "carType"
+	facetNumberSeats    = "numberSeats"
+	facetFreeKilometers = "freeKilometers"
+	facetVollkasko      = "vollkasko"
+	facetScanned        = "scanned" // offers within region and time range, reported as metric
+)
+
+// Columns of the matching CTE, covering the result page and all filter predicates.
+// free_kilometers is nullable, a missing value counts as 0 like in the in-memory repository,
+// so buckets, sort values and cursors never see NULL.
+const (
+	pageColumns  = "o.id, o.data, o.price, o.car_type, o.number_seats, o.only_vollkasko, COALESCE(o.free_kilometers, 0) AS free_kilometers, o.start_date, o.end_date"
+	facetColumns = "o.price, o.car_type, o.number_seats, o.only_vollkasko, COALESCE(o.free_kilometers, 0) AS free_kilometers"
+)
+
+// queryArgs collects the arguments of a statement and hands out their placeholders
+type queryArgs []interface{}
+
+func (a *queryArgs) add(value interface{}) string {
+	*a = append(*a, value)
+	return "$" + strconv.Itoa(len(*a))
+}
+
+// sortColumns maps the sort keys to their SQL expressions on the matching CTE
+var sortColumns = map[models.SortKey]string{
+	models.SortByPrice:          "price",
+	models.SortByFreeKilometers: "free_kilometers",
+	models.SortByNumberSeats:    "number_seats",
+	models.SortByDuration:       "(end_date - start_date)",
+	models.SortByStartDate:      "start_date",
+}
+
+// offerQuery plans the statements needed to answer a search request
+type offerQuery struct {
+	params models.OfferFilterParams
+	sort   models.OfferSort
+}
+
+func planOfferQuery(params models.OfferFilterParams) (offerQuery, error) {
+	sort, ok := models.ParseOfferSort(params.SortOrder)
+	if !ok {
+		return offerQuery{}, fmt.Errorf("unsupported sort order %q", params.SortOrder)
+	}
+	return offerQuery{params: params, sort: sort}, nil
+}
+
+// matching renders the CTE selecting every offer in the region subtree and time window.
+// region_closure lists every region below the searched one, including itself.
+func (q offerQuery) matching(args *queryArgs, columns string) string {
+	return `
+		WITH matching AS (
+			SELECT ` + columns + `
+			FROM offers o
+			JOIN region_closure rc ON o.most_specific_region_id = rc.descendant_id
+			WHERE rc.ancestor_id = ` + args.add(q.params.RegionID) + `
+				AND o.start_date >= ` + args.add(q.params.TimeRangeStart) + `
+				AND o.end_date <= ` + args.add(q.params.TimeRangeEnd) + `
+				AND o.end_date - o.start_date >= ` + args.add(q.params.NumberDays*24*3600*1000) + `
+		)`
+}
+
+// filters renders the optional filters as a WHERE clause, leaving out the excluded one
+func (q offerQuery) filters(args *queryArgs, except offerFilter) string {
+	return where(q.conditions(args, except))
+}
+
+// conditions renders the predicates of the optional filters, leaving out the excluded one
+func (q offerQuery) conditions(args *queryArgs, except offerFilter) []string {
+	var conditions []string
+	if q.params.MinNumberSeats != nil && except != filterNumberSeats {
+		conditions = append(conditions, "number_seats >= "+args.add(*q.params.MinNumberSeats))
+	}
+	if except != filterPrice {
+		if q.params.MinPrice != nil {
+			conditions = append(conditions, "price >= "+args.add(*q.params.MinPrice))
+		}
+		if q.params.MaxPrice != nil {
+			conditions = append(conditions, "price < "+args.add(*q.params.MaxPrice))
+		}
+	}
+	if q.params.CarType != nil && except != filterCarType {
+		conditions = append(conditions, "car_type = "+args.add(*q.params.CarType))
+	}
+	if q.params.OnlyVollkasko != nil && except != filterVollkasko {
+		conditions = append(conditions, "only_vollkasko = "+args.add(*q.params.OnlyVollkasko))
+	}
+	if q.params.MinFreeKilometer != nil && except != filterFreeKilometers {
+		conditions = append(conditions, "free_kilometers >= "+args.add(*q.params.MinFreeKilometer))
+	}
+	return conditions
+}
+
+func where(conditions []string) string {
+	if len(conditions) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(conditions, " AND ")
+}
+
+// orderBy renders the sort order. Offers with the same sort value are sorted by ID ascending.
+func (q offerQuery) orderBy() string {
+	if q.sort.Descending {
+		return " ORDER BY " + sortColumns[q.sort.Key] + " DESC, id"
+	}
+	return " ORDER BY " + sortColumns[q.sort.Key] + ", id"
+}
+
+// after renders the keyset condition selecting the offers behind the cursor
+func (q offerQuery) after(args *queryArgs) string {
+	column := sortColumns[q.sort.Key]
+	value, id := args.add(q.params.After.Value), args.add(q.params.After.ID)
+	if q.sort.Descending {
+		return "(" + column + " < " + value + " OR (" + column + " = " + value + " AND id > " + id + "))"
+	}
+	return "(" + column + ", id) > (" + value + ", " + id + ")"
+}
+
+// pageSQL renders the statement returning the requested page of offers
+func (q offerQuery) pageSQL() (string, []interface{}) {
+	args := &queryArgs{}
+
+	query := q.matching(args, pageColumns)
+
+	conditions := q.conditions(args, filterNone)
+	if q.params.After != nil {
+		conditions = append(conditions, q.after(args))
+	}
+	query += `
+		SELECT id, data, ` + sortColumns[q.sort.Key] + `
+		FROM matching` + where(conditions)
+
+	// Add sorting and pagination, keyset pages start behind the cursor instead of an offset
+	query += q.orderBy() + ` LIMIT ` + args.add(q.params.PageSize)
+	if !q.params.KeysetPagination {
+		query += ` OFFSET ` + args.add(q.params.Page*q.params.PageSize)
+	}
+
+	return query, *args
+}
+
+// deleteSQL renders the statement deleting every offer that satisfies all filters of the search,
+// or only counting them for a dry run. Sort order and pagination do not apply.
+func (q offerQuery) deleteSQL(dryRun bool) (string, []interface{}) {
+	args := &queryArgs{}
+
+	query := q.matching(args, "o.id, "+facetColumns)
+	matched := `
+		FROM matching` + q.filters(args, filterNone)
+
+	if dryRun {
+		return query + `
+		SELECT COUNT(*)` + matched, *args
+	}
+	return query + `
+		DELETE FROM offers
+		WHERE id IN (SELECT id` + matched + `)`, *args
+}
+
+// nextCursor returns the cursor of the following page in keyset mode. There is none when the
+// page is not full, as no offers are left behind it.
+func (q offerQuery) nextCursor(pageLength int, lastValue int64, lastID string) string {
+	if !q.params.KeysetPagination || pageLength == 0 || pageLength < q.params.PageSize {
+		return ""
+	}
+	return models.OfferCursor{SortOrder: q.params.SortOrder, Value: lastValue, ID: lastID}.Encode()
+}
+
+// facetSQL renders one grouped statement returning all five facets as
+// (facet, bucket, label, count) rows, sorted by facet and bucket.
+func (q offerQuery) facetSQL() (string, []interface{}) {
+	args := &queryArgs{}
+
+	query := q.matching(args, facetColumns)
+
+	priceWidth := args.add(q.params.PriceRangeWidth)
+	query += `
+		SELECT '` + facetPrice + `' AS facet, (price / ` + priceWidth + `) * ` + priceWidth + ` AS bucket, '' AS label, COUNT(*)
+		FROM matching` + q.filters(args, filterPrice) + `
+		GROUP BY 2
+		UNION ALL
+		SELECT '` + facetCarType + `', 0, COALESCE(car_type, ''), COUNT(*)
+		FROM matching` + q.filters(args, filterCarType) + `
+		GROUP BY 3
+		UNION ALL
+		SELECT '` + facetNumberSeats + `', number_seats, '', COUNT(*)
+		FROM matching` + q.filters(args, filterNumberSeats) + `
+		GROUP BY 2`
+
+	kilometerWidth := args.add(q.params.MinFreeKilometerWidth)
+	query += `
+		UNION ALL
+		SELECT '` + facetFreeKilometers + `', (free_kilometers / ` + kilometerWidth + `) * ` + kilometerWidth + `, '', COUNT(*)
+		FROM matching` + q.filters(args, filterFreeKilometers) + `
+		GROUP BY 2
+		UNION ALL
+		SELECT '` + facetVollkasko + `', only_vollkasko::int, '', COUNT(*)
+		FROM matching` + q.filters(args, filterVollkasko) + `
+		GROUP BY 2
+		UNION ALL
+		SELECT '` + facetScanned + `', 0, '', COUNT(*)
+		FROM matching
+		ORDER BY 1, 2`
+
+	return query, *args
+}
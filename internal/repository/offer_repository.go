@@ -2,20 +2,56 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgconn"
 	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
-	"log"
+	"go.opentelemetry.io/otel/attribute"
+	"log/slog"
+	"regexp"
+	"server/internal/logging"
+	"server/internal/metrics"
 	"server/internal/models"
+	"server/internal/tracing"
 	"strconv"
 	"strings"
+	"time"
 )
 
 type OfferRepository interface {
-	DeleteOldOffers(ctx context.Context) error
-	CreateOffers(ctx context.Context, offers []models.Offer) error
-	GetOffers(c *fiber.Ctx, params models.OfferFilterParams) (pgx.Rows, error)
+	DeleteOldOffers(ctx context.Context) (int, error)
+	DeleteExpiredOffers(ctx context.Context, before int64, limit int) (int, error)
+	CountOffers(ctx context.Context) (int, error)
+	EstimateOffers(ctx context.Context) (int, error)
+	CreateOffers(ctx context.Context, offers []models.Offer, mode models.ConflictMode) (models.InsertResult, error)
+	GetOffers(ctx context.Context, params models.OfferFilterParams) (models.OfferQueryResponse, error)
+	GetOffer(ctx context.Context, id string) (models.Offer, error)
+	UpdateOffer(ctx context.Context, id string, update func(offer *models.Offer) error) (models.Offer, error)
+	DeleteOffers(ctx context.Context, ids []string) ([]string, error)
+	DeleteMatchingOffers(ctx context.Context, params models.OfferFilterParams, dryRun bool) (int, error)
+}
+
+// SQLSTATEs of unique and foreign key violations
+const (
+	uniqueViolation     = "23505"
+	foreignKeyViolation = "23503"
+)
+
+// ErrDuplicateOffer is returned when an offer ID already exists and conflicts are rejected
+var ErrDuplicateOffer = errors.New("offer already exists")
+
+// ErrOfferNotFound is returned when no offer has the requested ID
+var ErrOfferNotFound = errors.New("offer not found")
+
+// UnknownRegionsError is returned when offers reference regions that do not exist, e.g. because
+// the regions were removed by a reload after the offers were checked against the hierarchy
+type UnknownRegionsError struct {
+	RegionIDs []int
+}
+
+func (e *UnknownRegionsError) Error() string {
+	return fmt.Sprintf("offers reference unknown regions: %v", e.RegionIDs)
 }
 
 type offerRepository struct {
@@ -27,19 +63,127 @@ func NewOfferRepository(db *pgxpool.Pool) OfferRepository {
 	return &offerRepository{db: db}
 }
 
-// CreateOffers erstellt einen neuen Offer Datensatz in der Datenbank
-func (r *offerRepository) CreateOffers(ctx context.Context, offers []models.Offer) error {
+// PostgreSQL accepts at most 65535 bind parameters per statement, so large batches are
+// inserted in chunks of insertChunkSize offers.
+const (
+	offerInsertColumns = 10
+	insertChunkSize    = 65535 / offerInsertColumns
+)
+
+// CreateOffers erstellt neue Offer Datensätze in der Datenbank. Große Batches werden in
+// mehreren INSERTs innerhalb einer Transaktion geschrieben, damit der Batch atomar bleibt.
+// Bereits vorhandene IDs werden je nach mode abgelehnt, übersprungen oder aktualisiert.
+func (r *offerRepository) CreateOffers(ctx context.Context, offers []models.Offer, mode models.ConflictMode) (result models.InsertResult, err error) {
 	if len(offers) == 0 {
+		return result, nil
+	}
+
+	ctx, span := tracing.Start(ctx, "offerRepository.CreateOffers", attribute.Int("offers.count", len(offers)))
+	defer func() { tracing.End(span, err) }()
+
+	err = r.db.BeginFunc(ctx, func(tx pgx.Tx) error {
+		for start := 0; start < len(offers); start += insertChunkSize {
+			end := start + insertChunkSize
+			if end > len(offers) {
+				end = len(offers)
+			}
+
+			query, args := insertOffersSQL(offers[start:end], mode)
+			written, err := insertOffers(ctx, tx, query, args)
+			if err != nil {
+				return err
+			}
+			result.Inserted += written.Inserted
+			result.Updated += written.Updated
+			result.Skipped += end - start - written.Inserted - written.Updated
+		}
 		return nil
+	})
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+		err = fmt.Errorf("%w: %s", ErrDuplicateOffer, pgErr.Detail)
 	}
+	if errors.As(err, &pgErr) && pgErr.Code == foreignKeyViolation {
+		err = r.unknownRegions(ctx, offers, err)
+	}
+	if err != nil {
+		return models.InsertResult{}, err
+	}
+
+	return result, nil
+}
+
+// unknownRegions finds the regions of the offers that are missing in static_region_data after
+// the foreign key rejected the batch. Without any, e.g. after a concurrent reload added them again, cause is returned.
+func (r *offerRepository) unknownRegions(ctx context.Context, offers []models.Offer, cause error) error {
+	regionIDs := make([]int, 0, len(offers))
+	for _, offer := range offers {
+		regionIDs = append(regionIDs, offer.MostSpecificRegionID)
+	}
+
+	rows, err := r.db.Query(ctx, `
+		SELECT DISTINCT o.id FROM unnest($1::int[]) AS o(id)
+		WHERE NOT EXISTS (SELECT 1 FROM static_region_data r WHERE r.id = o.id)
+		ORDER BY 1`, regionIDs)
+	if err != nil {
+		return fmt.Errorf("%v; failed to find unknown regions: %v", cause, err)
+	}
+	defer rows.Close()
+
+	var unknown []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return fmt.Errorf("%v; failed to find unknown regions: %v", cause, err)
+		}
+		unknown = append(unknown, id)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("%v; failed to find unknown regions: %v", cause, err)
+	}
+	if len(unknown) == 0 {
+		return cause
+	}
+	return &UnknownRegionsError{RegionIDs: unknown}
+}
 
+// insertOffers runs one chunk of CreateOffers and counts the inserted and updated rows
+func insertOffers(ctx context.Context, tx pgx.Tx, query string, args []interface{}) (result models.InsertResult, err error) {
+	ctx, span := tracing.StartSQL(ctx, "INSERT offers", query)
+	defer func() { tracing.End(span, err) }()
+
+	rows, err := tx.Query(ctx, query, args...)
+	if err != nil {
+		return result, err
+	}
+	defer rows.Close()
+
+	// Every written row reports whether it was inserted or updated, skipped rows are not returned
+	for rows.Next() {
+		var inserted bool
+		if err := rows.Scan(&inserted); err != nil {
+			return result, err
+		}
+		if inserted {
+			result.Inserted++
+		} else {
+			result.Updated++
+		}
+	}
+	span.SetAttributes(attribute.Int("db.rows_affected", result.Inserted+result.Updated))
+	return result, rows.Err()
+}
+
+// insertOffersSQL builds one multi-row INSERT for the given offers
+func insertOffersSQL(offers []models.Offer, mode models.ConflictMode) (string, []interface{}) {
 	var queryBuilder strings.Builder
 	queryBuilder.WriteString(`
 	  INSERT INTO offers (id, data, most_specific_region_id, start_date, end_date, number_seats, price, car_type, only_vollkasko, free_kilometers)
 	  VALUES
 	 `)
 
-	args := make([]interface{}, 0, len(offers)*10)
+	args := make([]interface{}, 0, len(offers)*offerInsertColumns)
 	for i, offer := range offers {
 		if i > 0 {
 			queryBuilder.WriteString(", ")
@@ -49,77 +193,345 @@ func (r *offerRepository) CreateOffers(ctx context.Context, offers []models.Offe
 		args = append(args, offer.ID, offer.Data, offer.MostSpecificRegionID, offer.StartDate, offer.EndDate, offer.NumberSeats, offer.Price, offer.CarType, offer.OnlyVollkasko, offer.FreeKilometers)
 	}
 
-	query := queryBuilder.String()
-	_, err := r.db.Exec(ctx, query, args...)
-	if err != nil {
-		return err
+	switch mode {
+	case models.ConflictIgnore:
+		queryBuilder.WriteString(` ON CONFLICT (id) DO NOTHING`)
+	case models.ConflictUpdate:
+		queryBuilder.WriteString(` ON CONFLICT (id) DO UPDATE SET
+		data = EXCLUDED.data,
+		most_specific_region_id = EXCLUDED.most_specific_region_id,
+		start_date = EXCLUDED.start_date,
+		end_date = EXCLUDED.end_date,
+		number_seats = EXCLUDED.number_seats,
+		price = EXCLUDED.price,
+		car_type = EXCLUDED.car_type,
+		only_vollkasko = EXCLUDED.only_vollkasko,
+		free_kilometers = EXCLUDED.free_kilometers`)
 	}
 
-	return nil
+	// xmax is only set for rows that already existed, i.e. were updated
+	queryBuilder.WriteString(` RETURNING xmax = 0`)
+
+	return queryBuilder.String(), args
 }
 
 // DeleteOldOffers löscht veraltete Angebote aus der Datenbank.
-func (r *offerRepository) DeleteOldOffers(ctx context.Context) error {
+func (r *offerRepository) DeleteOldOffers(ctx context.Context) (int, error) {
 	query := `
         DELETE FROM offers
-        WHERE end_date < extract(epoch from now())*1000;
+        WHERE end_date < (extract(epoch from now())*1000)::bigint;
     `
-	_, err := r.db.Exec(ctx, query)
+	ctx, span := tracing.StartSQL(ctx, "DELETE offers", query)
+	tag, err := r.db.Exec(ctx, query)
+	tracing.End(span, err)
 	if err != nil {
-		log.Printf("Failed to delete old offers: %v\n", err)
+		slog.ErrorContext(ctx, "Failed to delete old offers", "error", err)
+		return 0, err
+	}
+	return int(tag.RowsAffected()), nil
+}
+
+// DeleteExpiredOffers löscht höchstens limit Angebote, die vor before (Unix-Millisekunden) enden.
+// Die Zeilen werden in kurzen Transaktionen gelöscht, von anderen gesperrte Zeilen werden übersprungen.
+func (r *offerRepository) DeleteExpiredOffers(ctx context.Context, before int64, limit int) (deleted int, err error) {
+	query := `
+        DELETE FROM offers
+        WHERE id IN (
+            SELECT id FROM offers
+            WHERE end_date < $1
+            LIMIT $2
+            FOR UPDATE SKIP LOCKED
+        )`
+	ctx, span := tracing.StartSQL(ctx, "DELETE offers", query)
+	defer func() { tracing.End(span, err) }()
+
+	tag, err := r.db.Exec(ctx, query, before, limit)
+	if err != nil {
+		return 0, err
+	}
+	span.SetAttributes(attribute.Int("db.rows_affected", int(tag.RowsAffected())))
+	return int(tag.RowsAffected()), nil
+}
+
+// offerColumns are the columns of an offer in the order scanOffer reads them
+const offerColumns = `id, data, most_specific_region_id, start_date, end_date, number_seats, price, COALESCE(car_type, ''), only_vollkasko, COALESCE(free_kilometers, 0)`
+
+// scanOffer reads a row of offerColumns, a missing row is ErrOfferNotFound
+func scanOffer(row pgx.Row) (models.Offer, error) {
+	var offer models.Offer
+	err := row.Scan(&offer.ID, &offer.Data, &offer.MostSpecificRegionID, &offer.StartDate, &offer.EndDate,
+		&offer.NumberSeats, &offer.Price, &offer.CarType, &offer.OnlyVollkasko, &offer.FreeKilometers)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return models.Offer{}, ErrOfferNotFound
+	}
+	return offer, err
+}
+
+// GetOffer liefert ein einzelnes Angebot.
+func (r *offerRepository) GetOffer(ctx context.Context, id string) (offer models.Offer, err error) {
+	query := `SELECT ` + offerColumns + ` FROM offers WHERE id = $1`
+	ctx, span := tracing.StartSQL(ctx, "SELECT offer", query)
+	defer func() { tracing.End(span, err) }()
+
+	return scanOffer(r.db.QueryRow(ctx, query, id))
+}
+
+// UpdateOffer sperrt das Angebot, ändert es mit update und schreibt es in derselben Transaktion zurück.
+// Gibt update einen Fehler zurück, bleibt das Angebot unverändert.
+func (r *offerRepository) UpdateOffer(ctx context.Context, id string, update func(offer *models.Offer) error) (offer models.Offer, err error) {
+	ctx, span := tracing.Start(ctx, "offerRepository.UpdateOffer")
+	defer func() { tracing.End(span, err) }()
+
+	err = r.db.BeginFunc(ctx, func(tx pgx.Tx) error {
+		selectQuery := `SELECT ` + offerColumns + ` FROM offers WHERE id = $1 FOR UPDATE`
+		selectCtx, selectSpan := tracing.StartSQL(ctx, "SELECT offer", selectQuery)
+		offer, err = scanOffer(tx.QueryRow(selectCtx, selectQuery, id))
+		tracing.End(selectSpan, err)
+		if err != nil {
+			return err
+		}
+
+		if err := update(&offer); err != nil {
+			return err
+		}
+
+		updateQuery := `
+		UPDATE offers SET data = $2, most_specific_region_id = $3, start_date = $4, end_date = $5,
+			number_seats = $6, price = $7, car_type = $8, only_vollkasko = $9, free_kilometers = $10
+		WHERE id = $1`
+		updateCtx, updateSpan := tracing.StartSQL(ctx, "UPDATE offers", updateQuery)
+		_, err = tx.Exec(updateCtx, updateQuery, offer.ID, offer.Data, offer.MostSpecificRegionID, offer.StartDate, offer.EndDate,
+			offer.NumberSeats, offer.Price, offer.CarType, offer.OnlyVollkasko, offer.FreeKilometers)
+		tracing.End(updateSpan, err)
 		return err
+	})
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == foreignKeyViolation {
+		err = &UnknownRegionsError{RegionIDs: []int{offer.MostSpecificRegionID}}
+	}
+	if err != nil {
+		return models.Offer{}, err
+	}
+	return offer, nil
+}
+
+// DeleteOffers löscht die Angebote mit den angegebenen IDs und liefert die IDs der gelöschten.
+func (r *offerRepository) DeleteOffers(ctx context.Context, ids []string) (deleted []string, err error) {
+	query := `DELETE FROM offers WHERE id = ANY($1) RETURNING id`
+	ctx, span := tracing.StartSQL(ctx, "DELETE offers", query)
+	defer func() { tracing.End(span, err) }()
+
+	rows, err := r.db.Query(ctx, query, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deleted = make([]string, 0, len(ids))
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		deleted = append(deleted, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
 	}
-	return nil
+	span.SetAttributes(attribute.Int("db.rows_affected", len(deleted)))
+	return deleted, nil
 }
 
-func (r *offerRepository) GetOffers(c *fiber.Ctx, params models.OfferFilterParams) (pgx.Rows, error) {
-	// Build SQL query dynamically
+// DeleteMatchingOffers löscht alle Angebote, die die Filter der Suche erfüllen, und liefert deren Anzahl.
+// Mit dryRun werden sie nur gezählt.
+func (r *offerRepository) DeleteMatchingOffers(ctx context.Context, params models.OfferFilterParams, dryRun bool) (deleted int, err error) {
+	query, args := offerQuery{params: params}.deleteSQL(dryRun)
+	operation := "DELETE offers"
+	if dryRun {
+		operation = "SELECT offers count"
+	}
+	ctx, span := tracing.StartSQL(ctx, operation, query)
+	defer func() { tracing.End(span, err) }()
+
+	if dryRun {
+		err = r.db.QueryRow(ctx, query, args...).Scan(&deleted)
+		return deleted, err
+	}
+	tag, err := r.db.Exec(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+	span.SetAttributes(attribute.Int("db.rows_affected", int(tag.RowsAffected())))
+	return int(tag.RowsAffected()), nil
+}
+
+// CountOffers zählt alle gespeicherten Angebote.
+func (r *offerRepository) CountOffers(ctx context.Context) (int, error) {
+	var count int
+	if err := r.db.QueryRow(ctx, "SELECT COUNT(*) FROM offers").Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count offers: %v", err)
+	}
+	return count, nil
+}
+
+// EstimateOffers schätzt die Anzahl der Angebote aus der Tabellenstatistik, ohne die Tabelle zu lesen.
+// Vor dem ersten ANALYZE ist reltuples -1, dann wird die laufend gepflegte Zahl lebender Zeilen verwendet.
+func (r *offerRepository) EstimateOffers(ctx context.Context) (int, error) {
 	query := `
-		WITH RECURSIVE SubRegions AS (
-		-- Base case: Start with the given region ID
-		SELECT id, parent_id
-		FROM static_region_data
-		WHERE id = $1
-
-			UNION ALL
-		
-			-- Recursive case: Find children of the current regions
-			SELECT sr.id, sr.parent_id
-			FROM static_region_data sr
-			INNER JOIN SubRegions sbr ON sr.parent_id = sbr.id
-		)
-		SELECT o.*
-		FROM offers o
-		JOIN SubRegions sr ON o.most_specific_region_id = sr.id
-		WHERE o.start_date >= $2
-				AND o.end_date <= $3
-				AND o.end_date - start_date >= $4
-	`
-	args := []interface{}{params.RegionID, params.TimeRangeStart, params.TimeRangeEnd, params.NumberDays * 24 * 3600 * 1000}
-	argIdx := len(args)
-
-	// Add sorting and pagination
-	query += ` ORDER BY o.price, o.id ` + params.SortOrder[6:] + `, id LIMIT $` + strconv.Itoa(argIdx+1) + ` OFFSET $` + strconv.Itoa(argIdx+2)
-	args = append(args, params.PageSize, params.Page*params.PageSize)
-
-	//log.Printf("Query: %v\n", query)
-	//log.Printf("SQL query executed: %s, args: %v", query, args)
-	//formattedQuery := FormatQuery(query, args)
-	//fmt.Println("Formatted Query: ", formattedQuery)
-
-	// Execute the query
-	rows, err := r.db.Query(context.Background(), query, args...)
+        SELECT CASE WHEN c.reltuples < 0 THEN COALESCE(s.n_live_tup, 0) ELSE c.reltuples END::bigint
+        FROM pg_class c
+        LEFT JOIN pg_stat_user_tables s ON s.relid = c.oid
+        WHERE c.oid = 'offers'::regclass`
+	var count int
+	if err := r.db.QueryRow(ctx, query).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to estimate offers: %v", err)
+	}
+	return count, nil
+}
+
+// GetOffers liefert die gewünschte Seite der Angebote und alle Aggregationen über die gesamte Treffermenge.
+// Seite und Aggregationen werden als Batch in einem einzigen Roundtrip abgefragt.
+func (r *offerRepository) GetOffers(ctx context.Context, params models.OfferFilterParams) (response models.OfferQueryResponse, err error) {
+	plan, err := planOfferQuery(params)
+	if err != nil {
+		return models.OfferQueryResponse{}, err
+	}
+	pageQuery, pageArgs := plan.pageSQL()
+	facetQuery, facetArgs := plan.facetSQL()
+
+	ctx, span := tracing.Start(ctx, "offerRepository.GetOffers")
+	defer func() { tracing.End(span, err) }()
+
+	batch := &pgx.Batch{}
+	batch.Queue(pageQuery, pageArgs...)
+	batch.Queue(facetQuery, facetArgs...)
+
+	// Both statements are sent in one roundtrip. PostgreSQL runs them one after the other,
+	// so each span lasts until its rows are read and the page span includes the network latency.
+	start := time.Now()
+	results := r.db.SendBatch(ctx, batch)
+	defer results.Close()
+
+	// Read the requested page
+	offers, lastValue, err := readOfferPage(ctx, results, pageQuery)
+	if err != nil {
+		return models.OfferQueryResponse{}, err
+	}
+	pageRead := time.Now()
+	auditQuery(ctx, "page", pageQuery, pageArgs, len(offers), pageRead.Sub(start))
+
+	// Read the grouped facet counts
+	facets, facetRows, err := readOfferFacets(ctx, results, facetQuery)
 	if err != nil {
-		log.Printf("Query execution failed: %v\n", err)
+		return models.OfferQueryResponse{}, err
+	}
+	auditQuery(ctx, "facets", facetQuery, facetArgs, facetRows, time.Since(pageRead))
+
+	metrics.SearchRowsScanned.Observe(float64(facets.scanned))
+	span.SetAttributes(attribute.Int("offers.scanned", facets.scanned))
+
+	response = facets.response(params, offers)
+	if len(offers) > 0 {
+		response.NextCursor = plan.nextCursor(len(offers), lastValue, offers[len(offers)-1].ID)
 	}
 
-	return rows, err
+	return response, nil
 }
 
+// readOfferPage reads the result of pageSQL, lastValue is the sort value of the last offer
+func readOfferPage(ctx context.Context, results pgx.BatchResults, query string) (offers []models.ResponseOffer, lastValue int64, err error) {
+	_, span := tracing.StartSQL(ctx, "SELECT offers page", query)
+	defer func() { tracing.End(span, err) }()
+
+	rows, err := results.Query()
+	if err != nil {
+		slog.ErrorContext(ctx, "Query execution failed", "error", err)
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	offers = []models.ResponseOffer{}
+	for rows.Next() {
+		var offer models.ResponseOffer
+		if err := rows.Scan(&offer.ID, &offer.Data, &lastValue); err != nil {
+			slog.ErrorContext(ctx, "Row scan failed", "error", err)
+			return nil, 0, err
+		}
+		offers = append(offers, offer)
+	}
+	if err := rows.Err(); err != nil {
+		slog.ErrorContext(ctx, "Query execution failed", "error", err)
+		return nil, 0, err
+	}
+	span.SetAttributes(attribute.Int("db.rows_returned", len(offers)))
+	return offers, lastValue, nil
+}
+
+// readOfferFacets reads the grouped counts of facetSQL
+func readOfferFacets(ctx context.Context, results pgx.BatchResults, query string) (facets *offerFacets, returned int, err error) {
+	_, span := tracing.StartSQL(ctx, "SELECT offers facets", query)
+	defer func() { tracing.End(span, err) }()
+
+	rows, err := results.Query()
+	if err != nil {
+		slog.ErrorContext(ctx, "Facet query execution failed", "error", err)
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	facets = newOfferFacets()
+	for rows.Next() {
+		var facet, label string
+		var bucket, count int
+		if err := rows.Scan(&facet, &bucket, &label, &count); err != nil {
+			slog.ErrorContext(ctx, "Row scan failed", "error", err)
+			return nil, 0, err
+		}
+		facets.add(facet, bucket, label, count)
+		returned++
+	}
+	if err := rows.Err(); err != nil {
+		slog.ErrorContext(ctx, "Facet query execution failed", "error", err)
+		return nil, 0, err
+	}
+	span.SetAttributes(attribute.Int("db.rows_returned", returned))
+	return facets, returned, nil
+}
+
+// placeholder matches the bind parameters $1, $2, ... of a statement
+var placeholder = regexp.MustCompile(`\$(\d+)`)
+
+// FormatQuery renders the statement with its parameters as SQL literals, so that it can be
+// run in psql or passed to EXPLAIN ANALYZE. It is meant for logging, never for execution.
 func FormatQuery(query string, args []interface{}) string {
-	for i, arg := range args {
-		placeholder := fmt.Sprintf("$%d", i+1)
-		query = strings.Replace(query, placeholder, fmt.Sprintf("'%v'", arg), 1)
+	return placeholder.ReplaceAllStringFunc(query, func(match string) string {
+		index, err := strconv.Atoi(match[1:])
+		if err != nil || index < 1 || index > len(args) {
+			return match
+		}
+		switch arg := args[index-1].(type) {
+		case nil:
+			return "NULL"
+		case string:
+			return "'" + strings.ReplaceAll(arg, "'", "''") + "'"
+		case bool, int, int32, int64, float64:
+			return fmt.Sprint(arg)
+		default:
+			return "'" + strings.ReplaceAll(fmt.Sprint(arg), "'", "''") + "'"
+		}
+	})
+}
+
+// auditQuery logs one statement of a search in the audit mode
+func auditQuery(ctx context.Context, statement string, query string, args []interface{}, rows int, duration time.Duration) {
+	if !logging.Audit() {
+		return
 	}
-	return query
+	slog.InfoContext(ctx, "Query audit",
+		"statement", statement,
+		"sql", FormatQuery(query, args),
+		"params", args,
+		"rows", rows,
+		"duration", duration,
+	)
 }
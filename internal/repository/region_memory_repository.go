@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"server/internal/database"
+	"server/internal/models"
+	"server/internal/regions"
+)
+
+// NewRegionMemoryRepository liefert das Region-Repository zum In-Memory-Repository der Angebote.
+// Beide teilen sich Index und Buckets, deshalb muss offers von NewOfferMemoryRepository stammen.
+func NewRegionMemoryRepository(offers OfferRepository) RegionRepository {
+	return offers.(*offerMemoryRepository)
+}
+
+// GetRegions liefert die aktuelle Hierarchie des Speichers.
+func (r *offerMemoryRepository) GetRegions(ctx context.Context) (database.Region, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.regions.Root(), nil
+}
+
+// CountRegions liefert die Anzahl der Regionen im Index.
+func (r *offerMemoryRepository) CountRegions(ctx context.Context) (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.regions.Len(), nil
+}
+
+// ReplaceRegions tauscht die Hierarchie aus und ordnet alle Angebote den Buckets des neuen Index zu.
+func (r *offerMemoryRepository) ReplaceRegions(ctx context.Context, root database.Region) (models.RegionDiff, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	diff := regions.Diff(regions.Flatten(r.regions.Root()), regions.Flatten(root))
+
+	var referenced []int
+	for _, region := range diff.Removed {
+		if position, ok := r.regions.Position(region.ID); ok && len(r.buckets[position]) > 0 {
+			referenced = append(referenced, region.ID)
+		}
+	}
+	if len(referenced) > 0 {
+		return models.RegionDiff{}, fmt.Errorf("%w: %v", ErrRegionInUse, referenced)
+	}
+
+	index := regions.NewIndex(root)
+	buckets := make([][]models.Offer, index.Len())
+	for _, bucket := range r.buckets {
+		for _, offer := range bucket {
+			position, _ := index.Position(offer.MostSpecificRegionID)
+			buckets[position] = append(buckets[position], offer)
+			r.ids[offer.ID] = position
+		}
+	}
+
+	r.regions = index
+	r.buckets = buckets
+	r.regionVersion++
+
+	return diff, nil
+}
+
+// RegionVersion zählt die Aufrufe von ReplaceRegions.
+func (r *offerMemoryRepository) RegionVersion(ctx context.Context) (int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.regionVersion, nil
+}
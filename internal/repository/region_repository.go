@@ -0,0 +1,183 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"server/internal/database"
+	"server/internal/models"
+	"server/internal/regions"
+)
+
+type RegionRepository interface {
+	GetRegions(ctx context.Context) (database.Region, error)
+	CountRegions(ctx context.Context) (int, error)
+	ReplaceRegions(ctx context.Context, root database.Region) (models.RegionDiff, error)
+	RegionVersion(ctx context.Context) (int64, error)
+}
+
+// ErrRegionInUse is returned when a region that should be removed is still referenced by offers
+var ErrRegionInUse = errors.New("region is still referenced by offers")
+
+type regionRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewRegionRepository erstellt ein Repository für static_region_data.
+func NewRegionRepository(db *pgxpool.Pool) RegionRepository {
+	return &regionRepository{db: db}
+}
+
+// GetRegions liest die Hierarchie aus static_region_data. Sie kann durch ReplaceRegions
+// von regions.json abweichen.
+func (r *regionRepository) GetRegions(ctx context.Context) (database.Region, error) {
+	current, err := selectRegions(ctx, r.db)
+	if err != nil {
+		return database.Region{}, err
+	}
+	return regions.Build(current)
+}
+
+// CountRegions zählt die Zeilen von static_region_data
+func (r *regionRepository) CountRegions(ctx context.Context) (int, error) {
+	var count int
+	if err := r.db.QueryRow(ctx, "SELECT COUNT(*) FROM static_region_data").Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count regions: %v", err)
+	}
+	return count, nil
+}
+
+// ReplaceRegions gleicht static_region_data in einer Transaktion an die neue Hierarchie an.
+// Regionen, auf die noch Angebote verweisen, werden nicht gelöscht; dann bleibt alles unverändert.
+// Den Fremdschlüssel von offers prüft PostgreSQL auch gegen gleichzeitig geschriebene Angebote.
+// region_closure wird durch den Trigger auf static_region_data in derselben Transaktion neu aufgebaut.
+func (r *regionRepository) ReplaceRegions(ctx context.Context, root database.Region) (models.RegionDiff, error) {
+	var diff models.RegionDiff
+
+	err := r.db.BeginFunc(ctx, func(tx pgx.Tx) error {
+		// Concurrent reloads would otherwise diff against the same old state
+		if _, err := tx.Exec(ctx, "LOCK TABLE static_region_data IN SHARE ROW EXCLUSIVE MODE"); err != nil {
+			return err
+		}
+
+		rows, err := selectRegions(ctx, tx)
+		if err != nil {
+			return err
+		}
+		current := make(map[int]models.RegionSummary, len(rows))
+		for _, region := range rows {
+			current[region.ID] = region
+		}
+		diff = regions.Diff(current, regions.Flatten(root))
+
+		removed := make([]int, 0, len(diff.Removed))
+		for _, region := range diff.Removed {
+			removed = append(removed, region.ID)
+		}
+		if len(removed) > 0 {
+			// Lists all referenced regions up front. Offers written after this check make the
+			// DELETE fail on the foreign key of offers instead.
+			rows, err := tx.Query(ctx, "SELECT DISTINCT most_specific_region_id FROM offers WHERE most_specific_region_id = ANY($1) ORDER BY 1", removed)
+			if err != nil {
+				return err
+			}
+			var referenced []int
+			for rows.Next() {
+				var id int
+				if err := rows.Scan(&id); err != nil {
+					rows.Close()
+					return err
+				}
+				referenced = append(referenced, id)
+			}
+			rows.Close()
+			if err := rows.Err(); err != nil {
+				return err
+			}
+			if len(referenced) > 0 {
+				return fmt.Errorf("%w: %v", ErrRegionInUse, referenced)
+			}
+		}
+
+		// Each statement rebuilds region_closure once, so the regions are written set-based.
+		// New regions are inserted without parent first, so the foreign key holds
+		// regardless of the order in which parents and children are added.
+		if len(diff.Added) > 0 {
+			ids, names, _ := regionColumns(diff.Added)
+			if _, err := tx.Exec(ctx, `INSERT INTO static_region_data (id, name, parent_id)
+				SELECT r.id, r.name, NULL FROM unnest($1::int[], $2::text[]) AS r(id, name)`, ids, names); err != nil {
+				return err
+			}
+		}
+		changed := make([]models.RegionSummary, 0, len(diff.Added)+len(diff.Renamed)+len(diff.Moved))
+		changed = append(append(append(changed, diff.Added...), diff.Renamed...), diff.Moved...)
+		if len(changed) > 0 {
+			ids, names, parents := regionColumns(changed)
+			if _, err := tx.Exec(ctx, `UPDATE static_region_data s SET name = r.name, parent_id = r.parent_id
+				FROM unnest($1::int[], $2::text[], $3::int[]) AS r(id, name, parent_id)
+				WHERE s.id = r.id`, ids, names, parents); err != nil {
+				return err
+			}
+		}
+		if len(removed) > 0 {
+			if _, err := tx.Exec(ctx, "DELETE FROM static_region_data WHERE id = ANY($1)", removed); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == foreignKeyViolation && pgErr.ConstraintName == "offers_region_fk" {
+		err = fmt.Errorf("%w: %s", ErrRegionInUse, pgErr.Detail)
+	}
+	if err != nil {
+		return models.RegionDiff{}, err
+	}
+
+	return diff, nil
+}
+
+// regionColumns teilt Regionen in die Spalten auf, die unnest wieder zu Zeilen zusammensetzt.
+func regionColumns(summaries []models.RegionSummary) (ids []int, names []string, parents []*int) {
+	for _, region := range summaries {
+		ids = append(ids, region.ID)
+		names = append(names, region.Name)
+		parents = append(parents, region.ParentID)
+	}
+	return ids, names, parents
+}
+
+// RegionVersion liefert die Version von static_region_data, die jede Änderung der Regionen erhöht.
+func (r *regionRepository) RegionVersion(ctx context.Context) (int64, error) {
+	var version int64
+	if err := r.db.QueryRow(ctx, "SELECT version FROM region_version").Scan(&version); err != nil {
+		return 0, fmt.Errorf("failed to read region version: %v", err)
+	}
+	return version, nil
+}
+
+type querier interface {
+	Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error)
+}
+
+// selectRegions reads all rows of static_region_data
+func selectRegions(ctx context.Context, db querier) ([]models.RegionSummary, error) {
+	rows, err := db.Query(ctx, "SELECT id, name, parent_id FROM static_region_data ORDER BY id")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read static_region_data: %v", err)
+	}
+	defer rows.Close()
+
+	var result []models.RegionSummary
+	for rows.Next() {
+		var region models.RegionSummary
+		if err := rows.Scan(&region.ID, &region.Name, &region.ParentID); err != nil {
+			return nil, err
+		}
+		result = append(result, region)
+	}
+	return result, rows.Err()
+}
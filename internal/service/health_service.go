@@ -0,0 +1,110 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"server/internal/models"
+	"server/internal/repository"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// HealthCheck reports why a dependency is not usable
+type HealthCheck func(ctx context.Context) error
+
+// checkTimeout bounds every single check, so a hanging dependency cannot block the probe
+const checkTimeout = 2 * time.Second
+
+type HealthService struct {
+	started time.Time
+	ready   atomic.Bool
+	names   []string
+	checks  map[string]HealthCheck
+}
+
+// NewHealthService erstellt den Service für /healthz und /readyz. Die Regionen werden immer
+// geprüft; der Speicher gilt erst nach SetReady als aufgewärmt.
+func NewHealthService(regionRepo repository.RegionRepository) *HealthService {
+	s := &HealthService{started: time.Now(), checks: make(map[string]HealthCheck)}
+
+	s.AddCheck("storage", func(ctx context.Context) error {
+		if !s.ready.Load() {
+			return errors.New("warming up or shutting down")
+		}
+		return nil
+	})
+	s.AddCheck("regions", func(ctx context.Context) error {
+		count, err := regionRepo.CountRegions(ctx)
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			return errors.New("no regions loaded")
+		}
+		return nil
+	})
+
+	return s
+}
+
+// AddCheck registriert eine weitere Prüfung für /readyz. Nur beim Aufbau vor dem Start aufrufen.
+func (s *HealthService) AddCheck(name string, check HealthCheck) {
+	s.names = append(s.names, name)
+	s.checks[name] = check
+}
+
+// SetReady markiert das Ende des Aufwärmens bzw. den Beginn des Herunterfahrens
+func (s *HealthService) SetReady(ready bool) {
+	s.ready.Store(ready)
+}
+
+// Liveness meldet nur, dass der Prozess Anfragen beantwortet
+func (s *HealthService) Liveness() models.HealthResponse {
+	return models.HealthResponse{Status: "ok", Uptime: s.uptime(), Checks: map[string]models.CheckResult{}}
+}
+
+// Readiness führt alle Prüfungen parallel aus und ist nur bereit, wenn keine fehlschlägt
+func (s *HealthService) Readiness(ctx context.Context) (models.HealthResponse, bool) {
+	response := models.HealthResponse{Status: "ok", Uptime: s.uptime(), Checks: make(map[string]models.CheckResult, len(s.names))}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, name := range s.names {
+		wg.Add(1)
+		go func(name string, check HealthCheck) {
+			defer wg.Done()
+
+			checkCtx, cancel := context.WithTimeout(ctx, checkTimeout)
+			defer cancel()
+
+			start := time.Now()
+			err := check(checkCtx)
+			result := models.CheckResult{Status: "ok", Latency: time.Since(start).String()}
+			if err != nil {
+				result.Status = "failing"
+				result.Error = err.Error()
+			}
+
+			mu.Lock()
+			response.Checks[name] = result
+			mu.Unlock()
+		}(name, s.checks[name])
+	}
+	wg.Wait()
+
+	ready := true
+	for _, result := range response.Checks {
+		ready = ready && result.Status == "ok"
+	}
+	if !ready {
+		response.Status = "unavailable"
+	}
+
+	return response, ready
+}
+
+func (s *HealthService) uptime() string {
+	return fmt.Sprint(time.Since(s.started).Round(time.Second))
+}
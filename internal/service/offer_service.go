@@ -2,301 +2,232 @@ package service
 
 import (
 	"context"
-	"fmt"
-	"github.com/gofiber/fiber/v2"
-	"log"
+	"errors"
+	"go.opentelemetry.io/otel/attribute"
+	"server/internal/metrics"
 	"server/internal/models"
+	"server/internal/regions"
 	"server/internal/repository"
-	"strconv"
+	"server/internal/tracing"
+	"server/internal/validation"
+	"sort"
+	"time"
 )
 
 type OfferService struct {
 	offerRepository repository.OfferRepository
+	regions         *regions.Hierarchy
 }
 
-// NewOfferService erstellt einen neuen Service mit dem Repository.
-func NewOfferService(repo repository.OfferRepository) *OfferService {
-	return &OfferService{offerRepository: repo}
+// NewOfferService erstellt einen neuen Service mit dem Repository und der Regionshierarchie,
+// aus der auch static_region_data befüllt wird.
+func NewOfferService(repo repository.OfferRepository, hierarchy *regions.Hierarchy) *OfferService {
+	return &OfferService{offerRepository: repo, regions: hierarchy}
 }
 
-// CreateOffers erstellt ein neues Offer in der Datenbank
-func (s *OfferService) CreateOffers(ctx context.Context, offers []models.Offer) error {
-	// Add any business logic here, e.g., validation
-	return s.offerRepository.CreateOffers(ctx, offers)
-}
-
-// CleanUpOldOffers verwendet das Repository, um alte Angebote zu löschen.
-func (s *OfferService) CleanUpOldOffers(ctx context.Context) error {
-	return s.offerRepository.DeleteOldOffers(ctx)
-}
-
-// Get offers
-func (s *OfferService) GetOffers(c *fiber.Ctx, params models.OfferFilterParams) (models.OfferQueryResponse, error) {
-	rows, err := s.offerRepository.GetOffers(c, params)
-	defer rows.Close()
-	if err != nil {
-		return models.OfferQueryResponse{}, err
+// CreateOffers prüft die Angebote und speichert die gültigen in der Datenbank.
+// Ungültige Angebote werden einzeln im Ergebnis gemeldet, statt den ganzen Batch abzulehnen.
+// invalid enthält die Schemafehler je Angebot aus validation.ParseOffersRequest und darf nil sein.
+// mode legt fest, wie mit bereits vorhandenen IDs umgegangen wird.
+func (s *OfferService) CreateOffers(ctx context.Context, offers []models.Offer, invalid []validation.Errors, mode models.ConflictMode) (response models.CreateOffersResponse, err error) {
+	ctx, span := tracing.Start(ctx, "OfferService.CreateOffers", attribute.Int("offers.count", len(offers)), attribute.String("offers.conflict_mode", string(mode)))
+	defer func() { tracing.End(span, err) }()
+
+	response = models.CreateOffersResponse{Rejected: []models.RejectedOffer{}}
+
+	regionIndex := s.regions.Index()
+	valid := make([]models.Offer, 0, len(offers))
+	indexes := make([]int, 0, len(offers)) // position of every valid offer in the request
+	seen := make(map[string]struct{}, len(offers))
+	for i := range offers {
+		if i < len(invalid) && len(invalid[i]) > 0 {
+			response.Rejected = append(response.Rejected, rejectOffer(i, offers[i], invalid[i]))
+			continue
+		}
+		errs := validation.CheckOffer(&offers[i], regionIndex)
+		if _, duplicate := seen[offers[i].ID]; duplicate {
+			errs.Add("ID", "occurs more than once in the batch")
+		}
+		if len(errs) == 0 {
+			seen[offers[i].ID] = struct{}{}
+			valid = append(valid, offers[i])
+			indexes = append(indexes, i)
+			continue
+		}
+		response.Rejected = append(response.Rejected, rejectOffer(i, offers[i], errs))
 	}
 
-	// Process query results
-	offers := make([]models.ResponseOffer, 0, params.PageSize)
-	priceRangeCounts := make(map[string]int)
-	carTypeCounts := models.CarTypeCounts{Small: 0, Sports: 0, Luxury: 0, Family: 0}
-	seatsCount := make([]models.SeatsCount, 0)
-	freeKilometerCounts := make(map[string]int)
-	vollkaskoCount := models.VollkaskoCount{TrueCount: 0, FalseCount: 0}
-
-	rowCount := 0
-
-	for rows.Next() {
-		rowCount++
-
-		var id, data, carType string
-		var regionId, startDate, endDate, price, numberSeats, freeKilometers int
-		var onlyVollkasko bool
-
-		if err := rows.Scan(&id, &data, &regionId, &startDate, &endDate, &numberSeats, &price, &carType, &onlyVollkasko, &freeKilometers); err != nil {
-			log.Printf("Row scan failed: %v\n", err)
-			return models.OfferQueryResponse{}, err
-		}
-
-		// Check aggregate filters
-		minNumberSeatsFlag := params.MinNumberSeats == nil || numberSeats >= *params.MinNumberSeats
-		minPriceFlag := params.MinPrice == nil || price >= *params.MinPrice
-		maxPriceFlag := params.MaxPrice == nil || price < *params.MaxPrice
-		carTypeFlag := params.CarType == nil || carType == *params.CarType
-		onlyVollkaskoFlag := params.OnlyVollkasko == nil || onlyVollkasko == *params.OnlyVollkasko
-		minFreeKilometerFlag := params.MinFreeKilometer == nil || freeKilometers >= *params.MinFreeKilometer
-
-		// if all aggregate filters are satisfied (and not nil), add the offer to the response
-		if minNumberSeatsFlag && minPriceFlag && maxPriceFlag && carTypeFlag && onlyVollkaskoFlag && minFreeKilometerFlag {
-			//if maxPriceFlag && minFreeKilometerFlag {
-			offers = append(offers, models.ResponseOffer{ID: id, Data: data})
-		}
-
-		// Aggregate price ranges
-		if minNumberSeatsFlag && carTypeFlag && onlyVollkaskoFlag && minFreeKilometerFlag {
-			priceRangeKey := fmt.Sprintf("%d-%d", (price/params.PriceRangeWidth)*params.PriceRangeWidth, ((price/params.PriceRangeWidth)+1)*params.PriceRangeWidth)
-			priceRangeCounts[priceRangeKey]++
-		}
-
-		// Aggregate car type counts
-		if minNumberSeatsFlag && minPriceFlag && maxPriceFlag && onlyVollkaskoFlag && minFreeKilometerFlag {
-			switch carType {
-			case "small":
-				carTypeCounts.Small++
-			case "sports":
-				carTypeCounts.Sports++
-			case "luxury":
-				carTypeCounts.Luxury++
-			case "family":
-				carTypeCounts.Family++
+	// A reload may have removed regions since the check, the repository reports them and
+	// the batch is written again without their offers
+	result, err := s.offerRepository.CreateOffers(ctx, valid, mode)
+	var unknown *repository.UnknownRegionsError
+	for errors.As(err, &unknown) {
+		removed := make(map[int]bool, len(unknown.RegionIDs))
+		for _, regionID := range unknown.RegionIDs {
+			removed[regionID] = true
+		}
+		remaining, remainingIndexes := make([]models.Offer, 0, len(valid)), make([]int, 0, len(valid))
+		for i, offer := range valid {
+			if removed[offer.MostSpecificRegionID] {
+				response.Rejected = append(response.Rejected, rejectOffer(indexes[i], offer, validation.UnknownRegion()))
+				continue
 			}
+			remaining = append(remaining, offer)
+			remainingIndexes = append(remainingIndexes, indexes[i])
 		}
-
-		// Aggregate seats count
-		if minPriceFlag && maxPriceFlag && carTypeFlag && onlyVollkaskoFlag && minFreeKilometerFlag {
-			found := false
-			for i, sc := range seatsCount {
-				if sc.NumberSeats == numberSeats {
-					seatsCount[i].Count++
-					found = true
-					break
-				}
-			}
-			if !found {
-				seatsCount = append(seatsCount, models.SeatsCount{NumberSeats: numberSeats, Count: 1})
-			}
-		}
-
-		// Aggregate free kilometer ranges
-		if minNumberSeatsFlag && minPriceFlag && maxPriceFlag && carTypeFlag && onlyVollkaskoFlag {
-			freeKilometerKey := fmt.Sprintf("%d-%d", (freeKilometers/params.MinFreeKilometerWidth)*params.MinFreeKilometerWidth, ((freeKilometers/params.MinFreeKilometerWidth)+1)*params.MinFreeKilometerWidth)
-			freeKilometerCounts[freeKilometerKey]++
-		}
-
-		// Aggregate vollkasko count
-		if minNumberSeatsFlag && minPriceFlag && maxPriceFlag && carTypeFlag && minFreeKilometerFlag {
-			if onlyVollkasko {
-				vollkaskoCount.TrueCount++
-			} else {
-				vollkaskoCount.FalseCount++
-			}
+		if len(remaining) == len(valid) {
+			break
 		}
+		valid, indexes = remaining, remainingIndexes
+		result, err = s.offerRepository.CreateOffers(ctx, valid, mode)
 	}
+	sort.Slice(response.Rejected, func(i, j int) bool { return response.Rejected[i].Index < response.Rejected[j].Index })
 
-	// Transform aggregated data into required format
-	priceRanges := make([]models.PriceRange, 0, len(priceRangeCounts))
-	for key, count := range priceRangeCounts {
-		var start, end int
-		_, err := fmt.Sscanf(key, "%d-%d", &start, &end)
-		if err != nil {
-			return models.OfferQueryResponse{}, err
-		}
-		priceRanges = append(priceRanges, models.PriceRange{Start: start, End: end, Count: count})
-	}
+	metrics.OffersRejected.Add(float64(len(response.Rejected)))
+	span.SetAttributes(attribute.Int("offers.rejected", len(response.Rejected)))
 
-	freeKilometerRanges := make([]models.FreeKilometerRange, 0, len(freeKilometerCounts))
-	for key, count := range freeKilometerCounts {
-		var start, end int
-		_, err := fmt.Sscanf(key, "%d-%d", &start, &end)
-		if err != nil {
-			return models.OfferQueryResponse{}, err
-		}
-		freeKilometerRanges = append(freeKilometerRanges, models.FreeKilometerRange{Start: start, End: end, Count: count})
+	if err != nil {
+		return models.CreateOffersResponse{}, err
 	}
+	response.InsertResult = result
 
-	log.Println(strconv.Itoa(rowCount) + " | " + c.OriginalURL() + "\n")
+	metrics.OffersIngested.WithLabelValues("inserted").Add(float64(result.Inserted))
+	metrics.OffersIngested.WithLabelValues("updated").Add(float64(result.Updated))
+	metrics.OffersIngested.WithLabelValues("skipped").Add(float64(result.Skipped))
 
-	// Return the response
-	return models.OfferQueryResponse{
-		Offers:             offers,
-		PriceRanges:        priceRanges,
-		CarTypeCounts:      carTypeCounts,
-		SeatsCount:         seatsCount,
-		FreeKilometerRange: freeKilometerRanges,
-		VollkaskoCount:     vollkaskoCount,
-	}, nil
+	return response, nil
 }
 
-/*
-
-type DatabaseRow struct {
-	ID             string
-	Data           string
-	CarType        string
-	RegionId       int
-	StartDate      int
-	EndDate        int
-	Price          int
-	NumberSeats    int
-	FreeKilometers int
-	OnlyVollkasko  bool
+// rejectOffer reports an offer of the request with the errors why it was not stored
+func rejectOffer(index int, offer models.Offer, errs validation.Errors) models.RejectedOffer {
+	rejected := models.RejectedOffer{Index: index, ID: offer.ID, Errors: make([]string, 0, len(errs))}
+	for _, fieldError := range errs {
+		rejected.Errors = append(rejected.Errors, fieldError.Field+": "+fieldError.Message)
+	}
+	return rejected
 }
 
-func (s *OfferService) GetOffersIMPROVED(c *fiber.Ctx, params models.OfferFilterParams) (models.OfferQueryResponse, error) {
-	rows, err := s.offerRepository.GetOffers(c, params)
-	defer rows.Close()
+// CleanUpOldOffers verwendet das Repository, um alte Angebote zu löschen, und liefert deren Anzahl.
+func (s *OfferService) CleanUpOldOffers(ctx context.Context) (deleted int, err error) {
+	ctx, span := tracing.Start(ctx, "OfferService.CleanUpOldOffers")
+	defer func() { tracing.End(span, err) }()
+
+	deleted, err = s.offerRepository.DeleteOldOffers(ctx)
 	if err != nil {
-		return models.OfferQueryResponse{}, err
+		return 0, err
 	}
+	span.SetAttributes(attribute.Int("offers.deleted", deleted))
+	metrics.OffersDeleted.WithLabelValues("expired").Add(float64(deleted))
+	return deleted, nil
+}
 
-	// Process query results
-	offers := make([]models.ResponseOffer, 0, params.PageSize)
-	priceRangeCounts := make(map[string]int) // TODO check
-	carTypeCounts := models.CarTypeCounts{Small: 0, Sports: 0, Luxury: 0, Family: 0}
-	seatsCount := make([]models.SeatsCount, 0)
-	freeKilometerCounts := make(map[string]int) // TODO check
-	vollkaskoCount := models.VollkaskoCount{TrueCount: 0, FalseCount: 0}
-
-	rowCount := 0
-
-	for rows.Next() {
-		rowCount++
+// PurgeExpiredOffers löscht alle Angebote, die vor now enden, in Batches von batchSize, damit keine
+// Löschung die Tabelle lange sperrt. Bei einem Abbruch von ctx bleiben die bereits gelöschten Batches gelöscht.
+func (s *OfferService) PurgeExpiredOffers(ctx context.Context, now time.Time, batchSize int) (deleted int, err error) {
+	ctx, span := tracing.Start(ctx, "OfferService.PurgeExpiredOffers", attribute.Int("offers.batch_size", batchSize))
+	defer func() {
+		span.SetAttributes(attribute.Int("offers.deleted", deleted))
+		tracing.End(span, err)
+	}()
 
-		// Scanne die aktuelle Zeile in die record Datenstruktur
-		var record DatabaseRow
-		if err := rows.Scan(&record.ID, &record.Data, &record.RegionId, &record.StartDate, &record.EndDate, &record.NumberSeats, &record.Price, &record.CarType, &record.OnlyVollkasko, &record.FreeKilometers); err != nil {
-			log.Printf("Row scan failed: %v\n", err)
-			return models.OfferQueryResponse{}, err
+	for {
+		if err := ctx.Err(); err != nil {
+			return deleted, err
+		}
+		batch, err := s.offerRepository.DeleteExpiredOffers(ctx, now.UnixMilli(), batchSize)
+		if err != nil {
+			return deleted, err
 		}
+		deleted += batch
+		metrics.OffersDeleted.WithLabelValues("expired").Add(float64(batch))
+		if batch < batchSize {
+			return deleted, nil
+		}
+	}
+}
 
-		// Check aggregate filters
-		minNumberSeatsFlag := params.MinNumberSeats == nil || numberSeats >= *params.MinNumberSeats
-		minPriceFlag := params.MinPrice == nil || price >= *params.MinPrice
-		maxPriceFlag := params.MaxPrice == nil || price < *params.MaxPrice
-		carTypeFlag := params.CarType == nil || carType == *params.CarType
-		onlyVollkaskoFlag := params.OnlyVollkasko == nil || onlyVollkasko == *params.OnlyVollkasko
-		minFreeKilometerFlag := params.MinFreeKilometer == nil || freeKilometers >= *params.MinFreeKilometer
+// GetOffer liefert ein einzelnes Angebot, repository.ErrOfferNotFound für unbekannte IDs.
+func (s *OfferService) GetOffer(ctx context.Context, id string) (offer models.Offer, err error) {
+	ctx, span := tracing.Start(ctx, "OfferService.GetOffer", attribute.String("offers.id", id))
+	defer func() { tracing.End(span, err) }()
 
-		// if all aggregate filters are satisfied (and not nil), add the offer to the response
-		if minNumberSeatsFlag && minPriceFlag && maxPriceFlag && carTypeFlag && onlyVollkaskoFlag && minFreeKilometerFlag {
-			offers = append(offers, models.ResponseOffer{ID: id, Data: data})
-		}
+	return s.offerRepository.GetOffer(ctx, id)
+}
 
-		// Aggregate price ranges
-		if minNumberSeatsFlag && carTypeFlag && onlyVollkaskoFlag && minFreeKilometerFlag {
-			priceRangeKey := fmt.Sprintf("%d-%d", (price/params.PriceRangeWidth)*params.PriceRangeWidth, ((price/params.PriceRangeWidth)+1)*params.PriceRangeWidth)
-			priceRangeCounts[priceRangeKey]++
-		}
+// UpdateOffer wendet patch auf das Angebot an. Das geänderte Angebot muss dieselben Regeln erfüllen
+// wie beim Anlegen, sonst wird es nicht gespeichert und die Verstöße als validation.Errors gemeldet.
+func (s *OfferService) UpdateOffer(ctx context.Context, id string, patch models.OfferPatch) (offer models.Offer, err error) {
+	ctx, span := tracing.Start(ctx, "OfferService.UpdateOffer", attribute.String("offers.id", id))
+	defer func() { tracing.End(span, err) }()
+
+	regionIndex := s.regions.Index()
+	offer, err = s.offerRepository.UpdateOffer(ctx, id, func(offer *models.Offer) error {
+		patch.Apply(offer)
+		if errs := validation.CheckOffer(offer, regionIndex); len(errs) > 0 {
+			return errs
+		}
+		return nil
+	})
+	// The region was removed by a reload after the check
+	var unknown *repository.UnknownRegionsError
+	if errors.As(err, &unknown) {
+		return models.Offer{}, validation.UnknownRegion()
+	}
+	return offer, err
+}
 
-		// Aggregate car type counts
-		if minNumberSeatsFlag && minPriceFlag && maxPriceFlag && onlyVollkaskoFlag && minFreeKilometerFlag {
-			switch carType {
-			case "small":
-				carTypeCounts.Small++
-			case "sports":
-				carTypeCounts.Sports++
-			case "luxury":
-				carTypeCounts.Luxury++
-			case "family":
-				carTypeCounts.Family++
-			}
-		}
+// DeleteOffers zieht die Angebote mit den angegebenen IDs zurück und liefert die IDs der gelöschten.
+func (s *OfferService) DeleteOffers(ctx context.Context, ids []string) (deleted []string, err error) {
+	ctx, span := tracing.Start(ctx, "OfferService.DeleteOffers", attribute.Int("offers.count", len(ids)))
+	defer func() { tracing.End(span, err) }()
 
-		// Aggregate seats count
-		if minPriceFlag && maxPriceFlag && carTypeFlag && onlyVollkaskoFlag && minFreeKilometerFlag {
-			found := false
-			for i, sc := range seatsCount {
-				if sc.NumberSeats == numberSeats {
-					seatsCount[i].Count++
-					found = true
-					break
-				}
-			}
-			if !found {
-				seatsCount = append(seatsCount, models.SeatsCount{NumberSeats: numberSeats, Count: 1})
-			}
-		}
+	deleted, err = s.offerRepository.DeleteOffers(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	span.SetAttributes(attribute.Int("offers.deleted", len(deleted)))
+	metrics.OffersDeleted.WithLabelValues("withdrawn").Add(float64(len(deleted)))
+	return deleted, nil
+}
 
-		// Aggregate free kilometer ranges
-		if minNumberSeatsFlag && minPriceFlag && maxPriceFlag && carTypeFlag && onlyVollkaskoFlag {
-			freeKilometerKey := fmt.Sprintf("%d-%d", (freeKilometers/params.MinFreeKilometerWidth)*params.MinFreeKilometerWidth, ((freeKilometers/params.MinFreeKilometerWidth)+1)*params.MinFreeKilometerWidth)
-			freeKilometerCounts[freeKilometerKey]++
-		}
+// DeleteMatchingOffers löscht alle Angebote, die die Filter erfüllen, oder zählt sie nur im Probelauf.
+func (s *OfferService) DeleteMatchingOffers(ctx context.Context, deletion models.OfferDeletion) (response models.OfferDeletionResponse, err error) {
+	ctx, span := tracing.Start(ctx, "OfferService.DeleteMatchingOffers", attribute.Bool("offers.dry_run", deletion.DryRun))
+	defer func() { tracing.End(span, err) }()
 
-		// Aggregate vollkasko count
-		if minNumberSeatsFlag && minPriceFlag && maxPriceFlag && carTypeFlag && minFreeKilometerFlag {
-			if onlyVollkasko {
-				vollkaskoCount.TrueCount++
-			} else {
-				vollkaskoCount.FalseCount++
-			}
-		}
+	params := deletion.Filter
+	if deletion.AllRegions {
+		params.RegionID = s.regions.Index().Root().ID
 	}
 
-	// Transform aggregated data into required format
-	priceRanges := make([]models.PriceRange, 0, len(priceRangeCounts))
-	for key, count := range priceRangeCounts {
-		var start, end int
-		_, err := fmt.Sscanf(key, "%d-%d", &start, &end)
-		if err != nil {
-			return models.OfferQueryResponse{}, err
-		}
-		priceRanges = append(priceRanges, models.PriceRange{Start: start, End: end, Count: count})
+	matched, err := s.offerRepository.DeleteMatchingOffers(ctx, params, deletion.DryRun)
+	if err != nil {
+		return models.OfferDeletionResponse{}, err
 	}
+	span.SetAttributes(attribute.Int("offers.matched", matched))
 
-	freeKilometerRanges := make([]models.FreeKilometerRange, 0, len(freeKilometerCounts))
-	for key, count := range freeKilometerCounts {
-		var start, end int
-		_, err := fmt.Sscanf(key, "%d-%d", &start, &end)
-		if err != nil {
-			return models.OfferQueryResponse{}, err
-		}
-		freeKilometerRanges = append(freeKilometerRanges, models.FreeKilometerRange{Start: start, End: end, Count: count})
+	response = models.OfferDeletionResponse{Matched: matched, DryRun: deletion.DryRun}
+	if !deletion.DryRun {
+		response.Deleted = matched
+		metrics.OffersDeleted.WithLabelValues("filtered").Add(float64(matched))
 	}
+	return response, nil
+}
 
-	log.Println(strconv.Itoa(rowCount) + " | " + c.OriginalURL() + "\n")
+// GetOffers liefert die Angebote und Aggregationen zu den Suchparametern
+func (s *OfferService) GetOffers(ctx context.Context, params models.OfferFilterParams) (response models.OfferQueryResponse, err error) {
+	ctx, span := tracing.Start(ctx, "OfferService.GetOffers",
+		attribute.Int("offers.region_id", params.RegionID),
+		attribute.String("offers.sort_order", params.SortOrder),
+		attribute.Int("offers.page_size", params.PageSize),
+	)
+	defer func() { tracing.End(span, err) }()
 
-	// Return the response
-	return models.OfferQueryResponse{
-		Offers:             offers,
-		PriceRanges:        priceRanges,
-		CarTypeCounts:      carTypeCounts,
-		SeatsCount:         seatsCount,
-		FreeKilometerRange: freeKilometerRanges,
-		VollkaskoCount:     vollkaskoCount,
-	}, nil
-}
+	response, err = s.offerRepository.GetOffers(ctx, params)
+	if err != nil {
+		return models.OfferQueryResponse{}, err
+	}
+	span.SetAttributes(attribute.Int("offers.returned", len(response.Offers)))
 
-*/
+	return response, nil
+}
@@ -0,0 +1,112 @@
+package service
+
+import (
+	"context"
+	"server/internal/database"
+	"server/internal/models"
+	"server/internal/regions"
+	"server/internal/repository"
+	"sync"
+)
+
+type RegionService struct {
+	regions          *regions.Hierarchy
+	regionRepository repository.RegionRepository
+	reload           sync.Mutex
+	version          int64 // version of the regions in the hierarchy, see RefreshRegions
+	refreshed        bool
+}
+
+// NewRegionService erstellt einen Service, der die Regionshierarchie für die API bereitstellt
+// und zur Laufzeit austauschen kann.
+func NewRegionService(hierarchy *regions.Hierarchy, repo repository.RegionRepository) *RegionService {
+	return &RegionService{regions: hierarchy, regionRepository: repo}
+}
+
+// GetRegionTree liefert die gesamte Hierarchie im Format von regions.json
+func (s *RegionService) GetRegionTree() database.Region {
+	return s.regions.Index().Root()
+}
+
+// GetRegion liefert eine Region mit ihren Vorfahren und Unterregionen
+func (s *RegionService) GetRegion(regionID int) (models.RegionDetail, bool) {
+	index := s.regions.Index()
+	region, ok := index.Region(regionID)
+	if !ok {
+		return models.RegionDetail{}, false
+	}
+
+	detail := models.RegionDetail{
+		RegionSummary: summary(index, region.ID),
+		Ancestors:     []models.RegionSummary{},
+		Subregions:    region.Subregions,
+	}
+	for _, ancestor := range index.Ancestors(regionID) {
+		detail.Ancestors = append(detail.Ancestors, summary(index, ancestor.ID))
+	}
+	if detail.Subregions == nil {
+		detail.Subregions = []database.Region{}
+	}
+
+	return detail, true
+}
+
+// SearchRegions sucht Regionen, deren Name den Suchbegriff enthält
+func (s *RegionService) SearchRegions(name string, limit int) []models.RegionSummary {
+	index := s.regions.Index()
+	results := []models.RegionSummary{}
+	for _, regionID := range index.Search(name, limit) {
+		results = append(results, summary(index, regionID))
+	}
+	return results
+}
+
+// ReloadRegions übernimmt eine neue Hierarchie in den Speicher und tauscht danach den Index aus.
+// Schlägt das Speichern fehl, bleibt die bisherige Hierarchie vollständig erhalten.
+// Andere Instanzen übernehmen die Änderung mit RefreshRegions.
+func (s *RegionService) ReloadRegions(ctx context.Context, root database.Region) (models.RegionDiff, error) {
+	s.reload.Lock()
+	defer s.reload.Unlock()
+
+	diff, err := s.regionRepository.ReplaceRegions(ctx, root)
+	if err != nil {
+		return models.RegionDiff{}, err
+	}
+	s.regions.Replace(regions.NewIndex(root))
+
+	return diff, nil
+}
+
+// RefreshRegions lädt die Hierarchie aus dem Speicher neu, wenn sich dessen Version seit dem
+// letzten Aufruf geändert hat, etwa durch eine andere Instanz oder das Kommando reload-regions.
+func (s *RegionService) RefreshRegions(ctx context.Context) (changed bool, err error) {
+	s.reload.Lock()
+	defer s.reload.Unlock()
+
+	version, err := s.regionRepository.RegionVersion(ctx)
+	if err != nil {
+		return false, err
+	}
+	if s.refreshed && version == s.version {
+		return false, nil
+	}
+
+	// A change between both reads is picked up again by the next call
+	root, err := s.regionRepository.GetRegions(ctx)
+	if err != nil {
+		return false, err
+	}
+	s.regions.Replace(regions.NewIndex(root))
+	s.version, s.refreshed = version, true
+
+	return true, nil
+}
+
+func summary(index *regions.Index, regionID int) models.RegionSummary {
+	region, _ := index.Region(regionID)
+	summary := models.RegionSummary{ID: region.ID, Name: region.Name}
+	if parentID, ok := index.Parent(regionID); ok {
+		summary.ParentID = &parentID
+	}
+	return summary
+}
@@ -0,0 +1,53 @@
+package tracing
+
+import (
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"net/http"
+)
+
+// Middleware starts a server span for every request, continuing a trace passed in the
+// traceparent header. The span is stored in the user context of the request, handlers
+// pass c.UserContext() on so that service and repository spans become its children.
+func Middleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		carrier := propagation.HeaderCarrier(http.Header(c.GetReqHeaders()))
+		ctx := otel.GetTextMapPropagator().Extract(c.UserContext(), carrier)
+
+		method := utils.CopyString(c.Method())
+		ctx, span := tracer.Start(ctx, method,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(semconv.HTTPRequestMethodKey.String(method), semconv.URLPath(utils.CopyString(c.Path()))),
+		)
+		defer span.End()
+
+		c.SetUserContext(ctx)
+		err := c.Next()
+
+		status := c.Response().StatusCode()
+		var fiberErr *fiber.Error
+		if errors.As(err, &fiberErr) {
+			status = fiberErr.Code
+		} else if err != nil {
+			status = fiber.StatusInternalServerError
+		}
+
+		// The route is only known once the router matched the request
+		if fiberErr == nil || fiberErr.Code != fiber.StatusNotFound {
+			route := utils.CopyString(c.Route().Path)
+			span.SetName(method + " " + route)
+			span.SetAttributes(semconv.HTTPRoute(route))
+		}
+		span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+		if status >= fiber.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+		return err
+	}
+}
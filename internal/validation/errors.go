@@ -0,0 +1,28 @@
+package validation
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FieldError describes why a single parameter or body field was rejected
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Errors collects all field errors of a request
+type Errors []FieldError
+
+// Add records an error for the given field
+func (e *Errors) Add(field string, format string, args ...interface{}) {
+	*e = append(*e, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+func (e Errors) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, fieldError := range e {
+		messages = append(messages, fieldError.Field+": "+fieldError.Message)
+	}
+	return strings.Join(messages, "; ")
+}
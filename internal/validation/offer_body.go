@@ -0,0 +1,155 @@
+package validation
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"server/internal/models"
+	"strconv"
+	"strings"
+)
+
+// offerBody mirrors models.Offer with pointers, so missing required fields can be told apart from zero values
+type offerBody struct {
+	ID                   *string `json:"ID"`
+	Data                 *string `json:"data"`
+	MostSpecificRegionID *int64  `json:"mostSpecificRegionID"`
+	StartDate            *int64  `json:"startDate"`
+	EndDate              *int64  `json:"endDate"`
+	NumberSeats          *int64  `json:"numberSeats"`
+	Price                *int64  `json:"price"`
+	CarType              *string `json:"carType"`
+	HasVollkasko         *bool   `json:"hasVollkasko"`
+	FreeKilometers       *int64  `json:"freeKilometers"`
+}
+
+// offersBody keeps the offers undecoded, so that a schema violation only rejects its own offer
+type offersBody struct {
+	Offers []json.RawMessage `json:"offers"`
+}
+
+var conflictModes = map[models.ConflictMode]bool{models.ConflictReject: true, models.ConflictIgnore: true, models.ConflictUpdate: true}
+
+// ParseConflictMode reads the onConflict parameter of POST /api/offers, defaulting to reject
+func ParseConflictMode(value string) (models.ConflictMode, Errors) {
+	if value == "" {
+		return models.ConflictReject, nil
+	}
+
+	mode := models.ConflictMode(value)
+	if !conflictModes[mode] {
+		return "", Errors{{Field: "onConflict", Message: fmt.Sprintf("unsupported value %q", value)}}
+	}
+	return mode, nil
+}
+
+// ParseOffersRequest decodes the body of POST /api/offers and checks every offer against the
+// schema of the API specification. Each offer is checked on its own: invalid[i] lists the
+// violations of offers[i], whose fields are then incomplete. errs is only set when the body
+// cannot be parsed or contains no offers.
+func ParseOffersRequest(body []byte) (offers []models.Offer, invalid []Errors, errs Errors) {
+	var request offersBody
+	if errs := decodeBody(body, &request); len(errs) > 0 {
+		return nil, nil, errs
+	}
+
+	if len(request.Offers) == 0 {
+		errs.Add("offers", "must contain at least one offer")
+		return nil, nil, errs
+	}
+
+	offers = make([]models.Offer, len(request.Offers))
+	invalid = make([]Errors, len(request.Offers))
+	for i, raw := range request.Offers {
+		var body offerBody
+		if err := json.Unmarshal(raw, &body); err != nil {
+			invalid[i] = offerDecodeErrors(err)
+			continue
+		}
+		offers[i], invalid[i] = checkOfferBody("", body)
+	}
+
+	return offers, invalid, nil
+}
+
+// offerDecodeErrors reports why a single offer could not be decoded
+func offerDecodeErrors(err error) Errors {
+	var typeError *json.UnmarshalTypeError
+	if errors.As(err, &typeError) && typeError.Field != "" {
+		return Errors{{Field: fieldPath(typeError.Field), Message: "must be of type " + typeError.Type.String()}}
+	}
+	return Errors{{Field: "offer", Message: "must be an object"}}
+}
+
+func checkOfferBody(prefix string, body offerBody) (models.Offer, Errors) {
+	var errs Errors
+
+	str := func(name string, value *string) string {
+		if value == nil {
+			errs.Add(prefix+name, "is required")
+			return ""
+		}
+		return *value
+	}
+	integer := func(name string, value *int64, min, max int64) int64 {
+		if value == nil {
+			errs.Add(prefix+name, "is required")
+			return 0
+		}
+		if *value < min || *value > max {
+			errs.Add(prefix+name, "must be between %d and %d", min, max)
+		}
+		return *value
+	}
+
+	offer := models.Offer{
+		ID:                   str("ID", body.ID),
+		Data:                 str("data", body.Data),
+		MostSpecificRegionID: int(integer("mostSpecificRegionID", body.MostSpecificRegionID, 0, maxInt32)),
+		StartDate:            integer("startDate", body.StartDate, 0, math.MaxInt64),
+		EndDate:              integer("endDate", body.EndDate, 0, math.MaxInt64),
+		NumberSeats:          int(integer("numberSeats", body.NumberSeats, 0, maxUint8)),
+		Price:                int(integer("price", body.Price, 0, maxUint16)),
+		CarType:              str("carType", body.CarType),
+		FreeKilometers:       int(integer("freeKilometers", body.FreeKilometers, 0, maxUint16)),
+	}
+
+	if body.HasVollkasko == nil {
+		errs.Add(prefix+"hasVollkasko", "is required")
+	} else {
+		offer.OnlyVollkasko = *body.HasVollkasko
+	}
+
+	return offer, errs
+}
+
+// decodeBody decodes a JSON request body, a value of the wrong type is reported for its field
+func decodeBody(body []byte, target interface{}) Errors {
+	if err := json.Unmarshal(body, target); err != nil {
+		var typeError *json.UnmarshalTypeError
+		if errors.As(err, &typeError) && typeError.Field != "" {
+			return Errors{{Field: fieldPath(typeError.Field), Message: "must be of type " + typeError.Type.String()}}
+		}
+		return Errors{{Field: "body", Message: "cannot parse JSON"}}
+	}
+	return nil
+}
+
+// fieldPath turns the dotted path of a JSON decoding error ("offers.1.price") into
+// the notation used for field errors ("offers[1].price")
+func fieldPath(path string) string {
+	segments := strings.Split(path, ".")
+	var builder strings.Builder
+	for i, segment := range segments {
+		if _, err := strconv.Atoi(segment); err == nil && i > 0 {
+			builder.WriteString("[" + segment + "]")
+			continue
+		}
+		if i > 0 {
+			builder.WriteString(".")
+		}
+		builder.WriteString(segment)
+	}
+	return builder.String()
+}
@@ -0,0 +1,116 @@
+package validation
+
+import (
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"math"
+	"server/internal/models"
+)
+
+// maxDeleteIDs bounds the IDs of one POST /api/offers/delete
+const maxDeleteIDs = 10000
+
+type deleteOffersBody struct {
+	IDs []string `json:"ids"`
+}
+
+// ParseOfferID reads the offer ID from the path of /api/offers/:id in the canonical UUID form
+func ParseOfferID(c *fiber.Ctx) (string, Errors) {
+	id, ok := canonicalOfferID(c.Params("id"))
+	if !ok {
+		return "", Errors{{Field: "id", Message: "must be a UUID"}}
+	}
+	return id, nil
+}
+
+// canonicalOfferID parses an offer ID and returns it in the canonical lower-case form
+func canonicalOfferID(value string) (string, bool) {
+	id, err := uuid.Parse(value)
+	if err != nil || len(value) != 36 {
+		return "", false
+	}
+	return id.String(), true
+}
+
+// ParseDeleteOffersRequest decodes the body of POST /api/offers/delete, duplicate IDs are removed
+func ParseDeleteOffersRequest(body []byte) ([]string, Errors) {
+	var request deleteOffersBody
+	if errs := decodeBody(body, &request); len(errs) > 0 {
+		return nil, errs
+	}
+
+	var errs Errors
+	if len(request.IDs) == 0 || len(request.IDs) > maxDeleteIDs {
+		errs.Add("ids", "must contain between 1 and %d IDs", maxDeleteIDs)
+		return nil, errs
+	}
+
+	ids := make([]string, 0, len(request.IDs))
+	seen := make(map[string]bool, len(request.IDs))
+	for i, value := range request.IDs {
+		id, ok := canonicalOfferID(value)
+		if !ok {
+			errs.Add(fmt.Sprintf("ids[%d]", i), "must be a UUID")
+			continue
+		}
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return ids, nil
+}
+
+// ParseOfferPatch decodes the body of PATCH /api/offers/:id. Only the schema of the given fields
+// is checked here, the ingest rules apply to the patched offer (see CheckOffer).
+func ParseOfferPatch(body []byte) (models.OfferPatch, Errors) {
+	var request offerBody
+	if errs := decodeBody(body, &request); len(errs) > 0 {
+		return models.OfferPatch{}, errs
+	}
+
+	var errs Errors
+	integer := func(name string, value *int64, min, max int64) *int {
+		if value == nil {
+			return nil
+		}
+		if *value < min || *value > max {
+			errs.Add(name, "must be between %d and %d", min, max)
+		}
+		converted := int(*value)
+		return &converted
+	}
+	date := func(name string, value *int64) *int64 {
+		if value != nil && *value < 0 {
+			errs.Add(name, "must be between 0 and %d", int64(math.MaxInt64))
+		}
+		return value
+	}
+
+	patch := models.OfferPatch{
+		Data:                 request.Data,
+		MostSpecificRegionID: integer("mostSpecificRegionID", request.MostSpecificRegionID, 0, maxInt32),
+		StartDate:            date("startDate", request.StartDate),
+		EndDate:              date("endDate", request.EndDate),
+		NumberSeats:          integer("numberSeats", request.NumberSeats, 0, maxUint8),
+		Price:                integer("price", request.Price, 0, maxUint16),
+		CarType:              request.CarType,
+		OnlyVollkasko:        request.HasVollkasko,
+		FreeKilometers:       integer("freeKilometers", request.FreeKilometers, 0, maxUint16),
+	}
+
+	if request.ID != nil {
+		errs.Add("ID", "cannot be changed")
+	}
+	if patch == (models.OfferPatch{}) && request.ID == nil {
+		errs.Add("body", "must change at least one field")
+	}
+	if len(errs) > 0 {
+		return models.OfferPatch{}, errs
+	}
+	return patch, nil
+}
@@ -0,0 +1,214 @@
+package validation
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"math"
+	"server/internal/models"
+	"strconv"
+)
+
+// Value ranges of the parameters as specified in docs/openapi.yaml
+const (
+	maxUint8  = math.MaxUint8
+	maxUint16 = math.MaxUint16
+	maxUint32 = math.MaxUint32
+	maxInt32  = math.MaxInt32
+)
+
+// maxPageSize bounds the offers of one page, page*pageSize must stay below maxInt32
+const maxPageSize = 1000
+
+var carTypes = map[string]bool{"small": true, "sports": true, "luxury": true, "family": true}
+
+// queryParser reads query parameters and records every malformed one
+type queryParser struct {
+	c      *fiber.Ctx
+	errors Errors
+}
+
+func (p *queryParser) value(name string, required bool) (string, bool) {
+	if !p.c.Context().QueryArgs().Has(name) {
+		if required {
+			p.errors.Add(name, "is required")
+		}
+		return "", false
+	}
+	return p.c.Query(name), true
+}
+
+func (p *queryParser) integer(name string, required bool, min, max int64) (int64, bool) {
+	raw, ok := p.value(name, required)
+	if !ok {
+		return 0, false
+	}
+
+	value, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		p.errors.Add(name, "must be an integer")
+		return 0, false
+	}
+	if value < min || value > max {
+		p.errors.Add(name, "must be between %d and %d", min, max)
+		return 0, false
+	}
+
+	return value, true
+}
+
+func (p *queryParser) optionalInt(name string, min, max int64) *int {
+	value, ok := p.integer(name, false, min, max)
+	if !ok {
+		return nil
+	}
+	result := int(value)
+	return &result
+}
+
+func (p *queryParser) enum(name string, required bool, allowed map[string]bool) (string, bool) {
+	value, ok := p.value(name, required)
+	if !ok {
+		return "", false
+	}
+	if !allowed[value] {
+		p.errors.Add(name, "unsupported value %q", value)
+		return "", false
+	}
+	return value, true
+}
+
+func (p *queryParser) sortOrder(name string) (string, bool) {
+	value, ok := p.value(name, true)
+	if !ok {
+		return "", false
+	}
+	if _, known := models.ParseOfferSort(value); !known {
+		p.errors.Add(name, "unsupported value %q", value)
+		return "", false
+	}
+	return value, true
+}
+
+func (p *queryParser) boolean(name string) *bool {
+	raw, ok := p.value(name, false)
+	if !ok {
+		return nil
+	}
+	value, err := strconv.ParseBool(raw)
+	if err != nil {
+		p.errors.Add(name, "must be true or false")
+		return nil
+	}
+	return &value
+}
+
+// optionalFilters reads the optional filters shared by searching and deleting offers
+func (p *queryParser) optionalFilters(params *models.OfferFilterParams) {
+	params.MinNumberSeats = p.optionalInt("minNumberSeats", 0, maxUint8)
+	params.MinPrice = p.optionalInt("minPrice", 0, maxUint16)
+	params.MaxPrice = p.optionalInt("maxPrice", 0, maxUint16)
+	params.MinFreeKilometer = p.optionalInt("minFreeKilometer", 0, maxUint16)
+
+	if carType, ok := p.enum("carType", false, carTypes); ok {
+		params.CarType = &carType
+	}
+	params.OnlyVollkasko = p.boolean("onlyVollkasko")
+}
+
+// ParseOfferQueryParams reads the search parameters of GET /api/offers and checks them
+// against the constraints of the API specification.
+func ParseOfferQueryParams(c *fiber.Ctx) (models.OfferFilterParams, Errors) {
+	p := &queryParser{c: c}
+	var params models.OfferFilterParams
+
+	regionID, _ := p.integer("regionID", true, 0, maxInt32)
+	timeRangeStart, okStart := p.integer("timeRangeStart", true, 0, math.MaxInt64)
+	timeRangeEnd, okEnd := p.integer("timeRangeEnd", true, 0, math.MaxInt64)
+	numberDays, _ := p.integer("numberDays", true, 0, maxUint16)
+	sortOrder, _ := p.sortOrder("sortOrder")
+	page, okPage := p.integer("page", true, 0, maxUint32)
+	pageSize, okPageSize := p.integer("pageSize", true, 0, maxPageSize)
+	priceRangeWidth, _ := p.integer("priceRangeWidth", true, 1, maxUint32)
+	minFreeKilometerWidth, _ := p.integer("minFreeKilometerWidth", true, 1, maxUint32)
+
+	if okStart && okEnd && timeRangeEnd < timeRangeStart {
+		p.errors.Add("timeRangeEnd", "must not be before timeRangeStart")
+	}
+	if okPage && okPageSize && page*pageSize > maxInt32 {
+		p.errors.Add("page", "must not skip more than %d offers", maxInt32)
+	}
+
+	params.RegionID = int(regionID)
+	params.TimeRangeStart = int(timeRangeStart)
+	params.TimeRangeEnd = int(timeRangeEnd)
+	params.NumberDays = int(numberDays)
+	params.SortOrder = sortOrder
+	params.Page = int(page)
+	params.PageSize = int(pageSize)
+	params.PriceRangeWidth = int(priceRangeWidth)
+	params.MinFreeKilometerWidth = int(minFreeKilometerWidth)
+
+	p.optionalFilters(&params)
+
+	// Passing a cursor (empty for the first page) switches to keyset pagination
+	if raw, ok := p.value("cursor", false); ok {
+		params.KeysetPagination = true
+		if raw != "" {
+			after, err := models.DecodeOfferCursor(raw)
+			if err != nil {
+				p.errors.Add("cursor", err.Error())
+			} else if after.SortOrder != sortOrder {
+				p.errors.Add("cursor", "was issued for sortOrder %q", after.SortOrder)
+			} else {
+				params.After = &after
+			}
+		}
+	}
+
+	return params, p.errors
+}
+
+// offerDeleteFilters are the parameters of DELETE /api/offers/filter that select offers
+var offerDeleteFilters = []string{"regionID", "timeRangeStart", "timeRangeEnd", "numberDays",
+	"minNumberSeats", "minPrice", "maxPrice", "carType", "onlyVollkasko", "minFreeKilometer"}
+
+// ParseOfferDeleteParams reads the parameters of DELETE /api/offers/filter. The filters mean the
+// same as in a search but are all optional: without regionID every region matches and the time
+// window is unbounded. At least one filter is required, so that no request deletes everything by accident.
+func ParseOfferDeleteParams(c *fiber.Ctx) (models.OfferDeletion, Errors) {
+	p := &queryParser{c: c}
+	deletion := models.OfferDeletion{Filter: models.OfferFilterParams{TimeRangeEnd: math.MaxInt64}}
+
+	filtered := false
+	for _, name := range offerDeleteFilters {
+		filtered = filtered || c.Context().QueryArgs().Has(name)
+	}
+	if !filtered {
+		p.errors.Add("query", "at least one filter is required")
+	}
+
+	regionID, ok := p.integer("regionID", false, 0, maxInt32)
+	deletion.Filter.RegionID = int(regionID)
+	deletion.AllRegions = !ok
+
+	timeRangeStart, okStart := p.integer("timeRangeStart", false, 0, math.MaxInt64)
+	if okStart {
+		deletion.Filter.TimeRangeStart = int(timeRangeStart)
+	}
+	timeRangeEnd, okEnd := p.integer("timeRangeEnd", false, 0, math.MaxInt64)
+	if okEnd {
+		deletion.Filter.TimeRangeEnd = int(timeRangeEnd)
+	}
+	if deletion.Filter.TimeRangeEnd < deletion.Filter.TimeRangeStart {
+		p.errors.Add("timeRangeEnd", "must not be before timeRangeStart")
+	}
+	numberDays, _ := p.integer("numberDays", false, 0, maxUint16)
+	deletion.Filter.NumberDays = int(numberDays)
+
+	p.optionalFilters(&deletion.Filter)
+
+	if dryRun := p.boolean("dryRun"); dryRun != nil {
+		deletion.DryRun = *dryRun
+	}
+
+	return deletion, p.errors
+}
@@ -0,0 +1,58 @@
+package validation
+
+import (
+	"server/internal/models"
+)
+
+const millisecondsPerDay = 24 * 3600 * 1000
+
+// RegionChecker reports whether a region exists and is a leaf of the region hierarchy
+type RegionChecker interface {
+	IsLeaf(regionID int) bool
+}
+
+// CheckOffer applies the ingest rules to an offer that already satisfies the schema.
+// The ID is normalized to the canonical lower-case UUID form.
+func CheckOffer(offer *models.Offer, regions RegionChecker) Errors {
+	var errs Errors
+
+	if id, ok := canonicalOfferID(offer.ID); !ok {
+		errs.Add("ID", "must be a UUID")
+	} else {
+		offer.ID = id
+	}
+
+	if offer.StartDate%millisecondsPerDay != 0 {
+		errs.Add("startDate", "must be a whole day")
+	}
+	if offer.EndDate%millisecondsPerDay != 0 {
+		errs.Add("endDate", "must be a whole day")
+	}
+	if offer.StartDate >= offer.EndDate {
+		errs.Add("endDate", "must be after startDate")
+	}
+
+	if !carTypes[offer.CarType] {
+		errs.Add("carType", "unsupported value %q", offer.CarType)
+	}
+	if offer.Price <= 0 {
+		errs.Add("price", "must be positive")
+	}
+	if offer.NumberSeats <= 0 {
+		errs.Add("numberSeats", "must be positive")
+	}
+
+	if !regions.IsLeaf(offer.MostSpecificRegionID) {
+		errs = append(errs, UnknownRegion()...)
+	}
+
+	return errs
+}
+
+// UnknownRegion is the error of an offer whose region does not exist, also when the region
+// was removed after CheckOffer accepted the offer
+func UnknownRegion() Errors {
+	var errs Errors
+	errs.Add("mostSpecificRegionID", "must reference an existing leaf region")
+	return errs
+}
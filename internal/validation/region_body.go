@@ -0,0 +1,47 @@
+package validation
+
+import (
+	"encoding/json"
+	"fmt"
+	"server/internal/database"
+)
+
+// ParseRegionTree decodes a region hierarchy in the format of regions.json and checks it
+func ParseRegionTree(body []byte) (database.Region, Errors) {
+	var root database.Region
+	if err := json.Unmarshal(body, &root); err != nil {
+		return database.Region{}, Errors{{Field: "body", Message: "cannot parse JSON"}}
+	}
+
+	if errs := CheckRegionTree(root); len(errs) > 0 {
+		return database.Region{}, errs
+	}
+	return root, nil
+}
+
+// CheckRegionTree verifies that every region has a unique ID in the int32 range and a name
+func CheckRegionTree(root database.Region) Errors {
+	var errs Errors
+	seen := make(map[int]bool)
+
+	var check func(path string, region database.Region)
+	check = func(path string, region database.Region) {
+		if region.ID < 0 || region.ID > maxInt32 {
+			errs.Add(path+".id", "must be between 0 and %d", maxInt32)
+		}
+		if seen[region.ID] {
+			errs.Add(path+".id", "duplicate region ID %d", region.ID)
+		}
+		seen[region.ID] = true
+		if region.Name == "" {
+			errs.Add(path+".name", "is required")
+		}
+
+		for i, subregion := range region.Subregions {
+			check(fmt.Sprintf("%s.subregions[%d]", path, i), subregion)
+		}
+	}
+	check("region", root)
+
+	return errs
+}
@@ -0,0 +1,37 @@
+package validation
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"strconv"
+)
+
+const (
+	defaultRegionSearchLimit = 20
+	maxRegionSearchLimit     = 1000
+)
+
+// ParseRegionID reads the region ID from the path of /api/regions/:id
+func ParseRegionID(c *fiber.Ctx) (int, Errors) {
+	regionID, err := strconv.ParseInt(c.Params("id"), 10, 32)
+	if err != nil || regionID < 0 {
+		return 0, Errors{{Field: "id", Message: "must be a non-negative integer"}}
+	}
+	return int(regionID), nil
+}
+
+// ParseRegionSearchParams reads the parameters of GET /api/regions/search
+func ParseRegionSearchParams(c *fiber.Ctx) (string, int, Errors) {
+	p := &queryParser{c: c}
+
+	name, ok := p.value("name", true)
+	if ok && name == "" {
+		p.errors.Add("name", "must not be empty")
+	}
+
+	limit := defaultRegionSearchLimit
+	if value, ok := p.integer("limit", false, 1, maxRegionSearchLimit); ok {
+		limit = int(value)
+	}
+
+	return name, limit, p.errors
+}
@@ -0,0 +1,95 @@
+package tests
+
+import (
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"server/internal/config"
+	"testing"
+	"time"
+)
+
+func TestConfigPrecedence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	assert.NoError(t, os.WriteFile(path, []byte(`
+server:
+  address: ":8080"
+  readTimeout: 5s
+database:
+  maxConns: 20
+  minConns: 4
+storage: memory
+`), 0o644))
+
+	env := map[string]string{
+		"CONFIG_FILE":  path,
+		"DB_MAX_CONNS": "30",
+		"LOG_FORMAT":   "text",
+	}
+	lookupEnv := func(key string) (string, bool) {
+		value, ok := env[key]
+		return value, ok
+	}
+
+	cfg, args, err := config.Load([]string{"-dbMaxConns", "40", "-migrate=false", "migrate", "-to", "1"}, lookupEnv)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"migrate", "-to", "1"}, args)
+
+	assert.Equal(t, ":8080", cfg.Server.Address)           // file
+	assert.Equal(t, 5*time.Second, cfg.Server.ReadTimeout) // file
+	assert.Equal(t, 4, cfg.Database.MinConns)              // file
+	assert.Equal(t, "text", cfg.LogFormat)                 // environment
+	assert.Equal(t, 40, cfg.Database.MaxConns)             // flag beats environment and file
+	assert.False(t, cfg.Database.Migrate)                  // flag
+	assert.Equal(t, "memory", cfg.Storage)                 // file
+	assert.Equal(t, 30*time.Second, cfg.Server.WriteTimeout)
+}
+
+func TestConfigRejectsInvalidSettings(t *testing.T) {
+	noEnv := func(string) (string, bool) { return "", false }
+
+	_, _, err := config.Load([]string{"-storage", "redis"}, noEnv)
+	assert.Error(t, err)
+
+	_, _, err = config.Load([]string{"-dbMinConns", "5", "-dbMaxConns", "2"}, noEnv)
+	assert.Error(t, err)
+
+	_, _, err = config.Load([]string{"-readTimeout", "soon"}, noEnv)
+	assert.Error(t, err)
+
+	_, _, err = config.Load([]string{"-expiryBatchSize", "0"}, noEnv)
+	assert.Error(t, err)
+
+	_, _, err = config.Load([]string{"-expiryInterval", "-1m"}, noEnv)
+	assert.Error(t, err)
+
+	_, _, err = config.Load([]string{"-regionRefreshInterval", "-1s"}, noEnv)
+	assert.Error(t, err)
+
+	_, _, err = config.Load([]string{"-adminToken", "short"}, noEnv)
+	assert.Error(t, err, "admin tokens below 16 characters must be rejected")
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	assert.NoError(t, os.WriteFile(path, []byte("server:\n  port: 80\n"), 0o644))
+	_, _, err = config.Load([]string{"-config", path}, noEnv)
+	assert.Error(t, err, "unknown keys in the config file must be rejected")
+}
+
+func TestConfigDumpMasksSecrets(t *testing.T) {
+	env := map[string]string{
+		"DATABASE_URL": "postgres://postgres:pg%23pass123@db:5432/postgres?sslmode=disable",
+		"ADMIN_TOKEN":  "admin:token-secret-4711",
+	}
+	cfg, _, err := config.Load(nil, func(key string) (string, bool) {
+		value, ok := env[key]
+		return value, ok
+	})
+	assert.NoError(t, err)
+
+	dump := cfg.Dump()
+	assert.NotContains(t, dump, "pass123")
+	assert.Contains(t, dump, "databaseURL = postgres://postgres:xxxxx@db:5432/postgres?sslmode=disable")
+	assert.Contains(t, dump, "listen = :80")
+	assert.NotContains(t, dump, "secret-4711")
+	assert.Contains(t, dump, "adminToken = *****")
+}
@@ -0,0 +1,56 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"server/internal/controller"
+	"server/internal/models"
+	"server/internal/regions"
+	"server/internal/repository"
+	"server/internal/service"
+	"testing"
+)
+
+func TestHealthEndpoints(t *testing.T) {
+	offerRepo := repository.NewOfferMemoryRepository(regions.NewIndex(testRegionTree()))
+	healthService := service.NewHealthService(repository.NewRegionMemoryRepository(offerRepo))
+	healthController := controller.NewHealthController(healthService)
+
+	app := fiber.New()
+	app.Get("/healthz", healthController.LivenessHandler)
+	app.Get("/readyz", healthController.ReadinessHandler)
+
+	get := func(path string) (int, models.HealthResponse) {
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, path, nil))
+		assert.NoError(t, err)
+		var body models.HealthResponse
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		return resp.StatusCode, body
+	}
+
+	// Alive, but not ready while warming up
+	status, _ := get("/healthz")
+	assert.Equal(t, http.StatusOK, status)
+
+	status, body := get("/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, status)
+	assert.Equal(t, "failing", body.Checks["storage"].Status)
+	assert.Equal(t, "ok", body.Checks["regions"].Status)
+
+	healthService.SetReady(true)
+	status, body = get("/readyz")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "ok", body.Status)
+
+	// A failing dependency is reported with its error
+	healthService.AddCheck("database", func(ctx context.Context) error { return errors.New("connection refused") })
+	status, body = get("/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, status)
+	assert.Equal(t, "unavailable", body.Status)
+	assert.Equal(t, "connection refused", body.Checks["database"].Error)
+}
@@ -0,0 +1,122 @@
+package tests
+
+import (
+	"context"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"server/internal/database"
+	"server/internal/jobs"
+	"server/internal/metrics"
+	"server/internal/models"
+	"server/internal/regions"
+	"server/internal/repository"
+	"server/internal/service"
+	"server/internal/validation"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// setupOfferService creates the offer service on top of the in-memory repository, filled with testOffers
+func setupOfferService(t *testing.T) (*service.OfferService, repository.OfferRepository) {
+	rootRegion, err := database.LoadRegions("")
+	if err != nil {
+		t.Fatalf("Failed to load regions: %v", err)
+	}
+	offers, _, errs := validation.ParseOffersRequest([]byte(testOffers))
+	if len(errs) > 0 {
+		t.Fatalf("Invalid test offers: %v", errs)
+	}
+
+	repo := repository.NewOfferMemoryRepository(regions.NewIndex(rootRegion))
+	offerService := service.NewOfferService(repo, regions.NewHierarchy(regions.NewIndex(rootRegion)))
+	response, err := offerService.CreateOffers(context.Background(), offers, nil, models.ConflictReject)
+	assert.NoError(t, err)
+	assert.Equal(t, 10, response.Inserted)
+	return offerService, repo
+}
+
+// refusingLocker behaves like a lock held by another replica
+type refusingLocker struct{}
+
+func (refusingLocker) TryLock(ctx context.Context, name string) (func(), bool, error) {
+	return nil, false, nil
+}
+
+func TestPurgeExpiredOffersInBatches(t *testing.T) {
+	offerService, repo := setupOfferService(t)
+	expired := testutil.ToFloat64(metrics.OffersDeleted.WithLabelValues("expired"))
+
+	// Five offers end on June 13 or 14, 2020
+	deleted, err := offerService.PurgeExpiredOffers(context.Background(), time.UnixMilli(1592100000000), 2)
+	assert.NoError(t, err)
+	assert.Equal(t, 5, deleted)
+	assert.Equal(t, expired+5, testutil.ToFloat64(metrics.OffersDeleted.WithLabelValues("expired")))
+
+	count, err := repo.CountOffers(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 5, count)
+
+	deleted, err = offerService.PurgeExpiredOffers(context.Background(), time.UnixMilli(1592100000000), 2)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, deleted)
+}
+
+func TestExpireOffersJob(t *testing.T) {
+	offerService, repo := setupOfferService(t)
+	ok := testutil.ToFloat64(metrics.JobRuns.WithLabelValues(jobs.ExpireOffersJob, "ok"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	runner := jobs.NewRunner(nil)
+	runner.Add(jobs.ExpireOffers(offerService, 10*time.Millisecond, 3))
+	runner.Start(ctx)
+
+	// All test offers are from 2020
+	assert.Eventually(t, func() bool {
+		count, err := repo.CountOffers(context.Background())
+		return err == nil && count == 0
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Eventually(t, func() bool {
+		return testutil.ToFloat64(metrics.JobRuns.WithLabelValues(jobs.ExpireOffersJob, "ok")) >= ok+2
+	}, 5*time.Second, 10*time.Millisecond)
+
+	cancel()
+	runner.Wait()
+	assert.Greater(t, testutil.ToFloat64(metrics.JobLastSuccess.WithLabelValues(jobs.ExpireOffersJob)), 0.0)
+}
+
+func TestJobSkippedWhileLocked(t *testing.T) {
+	skipped := testutil.ToFloat64(metrics.JobRuns.WithLabelValues("locked-job", "skipped"))
+	var runs atomic.Int32
+
+	ctx, cancel := context.WithCancel(context.Background())
+	runner := jobs.NewRunner(refusingLocker{})
+	runner.Add(jobs.Job{Name: "locked-job", Interval: 10 * time.Millisecond, Run: func(ctx context.Context) error {
+		runs.Add(1)
+		return nil
+	}})
+	runner.Start(ctx)
+
+	assert.Eventually(t, func() bool {
+		return testutil.ToFloat64(metrics.JobRuns.WithLabelValues("locked-job", "skipped")) >= skipped+2
+	}, 5*time.Second, 10*time.Millisecond)
+	cancel()
+	runner.Wait()
+	assert.Equal(t, int32(0), runs.Load())
+}
+
+func TestLocalJobIgnoresLock(t *testing.T) {
+	var runs atomic.Int32
+
+	ctx, cancel := context.WithCancel(context.Background())
+	runner := jobs.NewRunner(refusingLocker{})
+	runner.Add(jobs.Job{Name: "local-job", Interval: 10 * time.Millisecond, Local: true, Run: func(ctx context.Context) error {
+		runs.Add(1)
+		return nil
+	}})
+	runner.Start(ctx)
+
+	assert.Eventually(t, func() bool { return runs.Load() >= 2 }, 5*time.Second, 10*time.Millisecond)
+	cancel()
+	runner.Wait()
+}
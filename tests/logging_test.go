@@ -0,0 +1,65 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"server/internal/logging"
+	"server/internal/repository"
+	"strings"
+	"testing"
+)
+
+func TestRequestLoggingAndQueryAudit(t *testing.T) {
+	var output bytes.Buffer
+	assert.NoError(t, logging.Setup(&output, "json", "debug", true))
+	defer logging.Setup(os.Stderr, "text", "info", false)
+
+	app := setupMemoryApp()
+
+	req := httptest.NewRequest(http.MethodGet, "/api/offers?regionID=1&timeRangeStart=0&timeRangeEnd=1692179200000&numberDays=1&sortOrder=price-asc&page=0&pageSize=10&priceRangeWidth=10&minFreeKilometerWidth=10", nil)
+	req.Header.Set(logging.RequestIDHeader, "search-1")
+	resp, err := app.Test(req)
+	assert.NoError(t, err)
+	assert.Equal(t, "search-1", resp.Header.Get(logging.RequestIDHeader))
+
+	// Every line is one JSON object, the messages of service and repository carry the request ID
+	messages := make(map[string]map[string]interface{})
+	for _, line := range strings.Split(strings.TrimSpace(output.String()), "\n") {
+		var record map[string]interface{}
+		if assert.NoError(t, json.Unmarshal([]byte(line), &record), line) {
+			messages[record["msg"].(string)] = record
+		}
+	}
+
+	audit := messages["Query audit"]
+	if assert.NotNil(t, audit) {
+		assert.Equal(t, "search-1", audit["requestID"])
+		assert.Equal(t, "INFO", audit["level"])
+		assert.Contains(t, audit, "duration")
+		assert.Contains(t, audit, "rows")
+	}
+
+	request := messages["request"]
+	if assert.NotNil(t, request) {
+		assert.Equal(t, "search-1", request["requestID"])
+		assert.Equal(t, "/api/offers", request["route"])
+		assert.Equal(t, float64(http.StatusOK), request["status"])
+	}
+
+	// Requests without ID get a generated one
+	resp, err = app.Test(httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	assert.NoError(t, err)
+	assert.Len(t, resp.Header.Get(logging.RequestIDHeader), 36)
+}
+
+func TestFormatQuery(t *testing.T) {
+	query := "SELECT * FROM offers WHERE car_type = $1 AND price >= $2 AND only_vollkasko = $3 AND id > $10"
+	args := []interface{}{"family's", 100, true, 4, 5, 6, 7, 8, 9, "b"}
+
+	assert.Equal(t, "SELECT * FROM offers WHERE car_type = 'family''s' AND price >= 100 AND only_vollkasko = true AND id > 'b'", repository.FormatQuery(query, args))
+	assert.Equal(t, "SELECT $1", repository.FormatQuery("SELECT $1", nil))
+}
@@ -2,223 +2,198 @@ package tests
 
 import (
 	"bytes"
-	"context"
 	"encoding/json"
-	"fmt"
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
-	"log"
 	"net/http"
 	"net/http/httptest"
-	"server/internal/controller"
 	"server/internal/database"
 	"server/internal/framework"
+	"server/internal/models"
+	"server/internal/regions"
 	"server/internal/repository"
-	"server/internal/service"
+	"sort"
 	"sync"
 	"testing"
 )
 
-// Mock setup for the application
-func setupApp() *fiber.App {
-	// Erstelle eine neue Instanz der App und richte die Routen ein
-	app := fiber.New()
+// testAdminToken is the admin token of the test applications
+const testAdminToken = "test-admin-token-0123456789"
 
-	// PostgreSQL-Verbindung herstellen
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
+// setupMemoryApp creates the application on top of the in-memory repository
+func setupMemoryApp() *fiber.App {
+	return setupMemoryAppWithRegions(testRegionTree())
+}
 
-	dbPool, err := database.ConnectDB(ctx)
-	if err != nil {
-		log.Fatalf("Failed to connect to database: %v", err)
-	}
+// setupMemoryAppWithRegions creates the in-memory application for the given region tree
+func setupMemoryAppWithRegions(rootRegion database.Region) *fiber.App {
+	offerRepo := repository.NewOfferMemoryRepository(regions.NewIndex(rootRegion))
+	app := framework.NewApp(framework.Dependencies{
+		Offers:     offerRepo,
+		Regions:    repository.NewRegionMemoryRepository(offerRepo),
+		RootRegion: rootRegion,
+		AdminToken: testAdminToken,
+	})
+	app.Health.SetReady(true)
+	return app.App
+}
 
-	// clean up databases
-	err = database.DropTables(ctx, dbPool)
-	if err != nil {
-		log.Fatalf("Failed to drop tables: %v", err)
-	}
-	err = database.Migrate(ctx, dbPool)
+// testBackends creates an empty application per storage backend. The stack tests run against
+// each of them: the in-memory repository always, PostgreSQL with -tags integration (postgres_test.go).
+var testBackends = map[string]func(t *testing.T) *fiber.App{
+	"memory": setupApp,
+}
+
+// setupApp creates the application with the region tree of the platform on top of the in-memory repository
+func setupApp(t *testing.T) *fiber.App {
+	rootRegion, err := database.LoadRegions("")
 	if err != nil {
-		log.Fatalf("Failed to migrate database: %v", err)
+		t.Fatalf("Failed to load regions: %v", err)
 	}
-
-	// Init components
-	offerRepo := repository.NewOfferRepository(dbPool)
-	offerService := service.NewOfferService(offerRepo)
-	offerController := controller.NewOfferController(offerService)
-
-	framework.RegisterRoutes(app, offerController)
-
-	return app
+	return setupMemoryAppWithRegions(rootRegion)
 }
 
-// TestGetOffers tests the GET /api/offers endpoint
-func TestGetOffersAdvanced(t *testing.T) {
-	app := setupApp()
-
-	TestPostOffers(t)
+// forEachBackend runs test once per backend, every run starts with an empty repository
+func forEachBackend(t *testing.T, test func(t *testing.T, app *fiber.App)) {
+	names := make([]string, 0, len(testBackends))
+	for name := range testBackends {
+		names = append(names, name)
+	}
+	sort.Strings(names)
 
-	req, err := http.NewRequest("GET", "/api/offers", nil)
-	if err != nil {
-		t.Fatal(err)
+	for _, name := range names {
+		setup := testBackends[name]
+		t.Run(name, func(t *testing.T) {
+			test(t, setup(t))
+		})
 	}
+}
 
-	// Set query parameters
-	q := req.URL.Query()
-	q.Add("regionID", "0")
-	q.Add("timeRangeStart", "1732104000000") // Example timestamp in ms
-	q.Add("timeRangeEnd", "1732449600000")   // Example timestamp in ms
-	q.Add("numberDays", "5")
-	q.Add("sortOrder", "price-asc")
-	q.Add("page", "1")
-	q.Add("pageSize", "10")
-	q.Add("priceRangeWidth", "500")
-	q.Add("minFreeKilometerWidth", "50")
-	req.URL.RawQuery = q.Encode()
-
-	// Send the request and capture the response
-	resp, err := app.Test(req)
+// postOffers sends a POST /api/offers and returns the decoded result, any status but 200 fails the test
+func postOffers(t *testing.T, app *fiber.App, body []byte) models.CreateOffersResponse {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/api/offers", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := app.Test(req, -1)
 	if err != nil {
-		t.Fatal(err)
+		t.Fatalf("Error during POST request: %v", err)
 	}
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
 
-	// Assert: Verify that the response status is OK (200)
-	assert.Equal(t, 200, resp.StatusCode)
+	var result models.CreateOffersResponse
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+	return result
+}
 
-	// Optionally: Check if the response body contains the expected values
-	var responseBody map[string]interface{}
-	err = json.NewDecoder(resp.Body).Decode(&responseBody)
+// getOffers sends a GET /api/offers with the given query and returns the decoded response
+func getOffers(t *testing.T, app *fiber.App, query string) models.OfferQueryResponse {
+	t.Helper()
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/api/offers?"+query, nil), -1)
 	if err != nil {
-		t.Fatal(err)
+		t.Fatalf("Error during GET request: %v", err)
 	}
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "Status code should be 200")
 
-	// Verify the returned query parameters in the response
-	assert.Equal(t, "1", responseBody["regionID"])
-	assert.Equal(t, "1732104000000", responseBody["timeRangeStart"])
-	assert.Equal(t, "1732449600000", responseBody["timeRangeEnd"])
-	assert.Equal(t, "5", responseBody["numberDays"])
-	assert.Equal(t, "price-asc", responseBody["sortOrder"])
-	assert.Equal(t, "1", responseBody["page"])
-	assert.Equal(t, "10", responseBody["pageSize"])
-	assert.Equal(t, "500", responseBody["priceRangeWidth"])
-	assert.Equal(t, "50", responseBody["minFreeKilometerWidth"])
+	var response models.OfferQueryResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		t.Fatalf("Error decoding response body: %v", err)
+	}
+	return response
+}
 
+// generateOffers creates n offers with random IDs in the leaf region 58 (Brandenburg Gate)
+func generateOffers(n int) []byte {
+	offers := make([]map[string]interface{}, 0, n)
+	for i := 0; i < n; i++ {
+		offers = append(offers, map[string]interface{}{
+			"ID":                   uuid.New().String(),
+			"data":                 "string",
+			"mostSpecificRegionID": 58,
+			"startDate":            1732060800000,
+			"endDate":              1732406400000,
+			"numberSeats":          5,
+			"price":                10000,
+			"carType":              "luxury",
+			"hasVollkasko":         true,
+			"freeKilometers":       120,
+		})
+	}
+	body, _ := json.Marshal(map[string]interface{}{"offers": offers})
+	return body
 }
 
-func TestGetOffers2(t *testing.T) {
-	// Setup der App
-	app := setupApp()
+// testOffers are ten offers in June 2020, spread over the whole region tree
+const testOffers = `{"offers":[{"ID":"68ed0a29-a7ae-42b4-bdfd-2f35462828ca","carType":"sports","data":"9Wm0fKJQxnqV+1R8Eie8g2D2hXjsX3H1Yri3S46Ev+Sil/L1sbn6UeaKQLxif+IlR1Srgx12Xpyaqx+gS+T4UnnSxyNKnpK49Jdag6i2BqmdIt7c3LZz891HVCMtrpTqevN5HaiRXy5yLGdix8PMAdxfj7qY5wgR1EXcn5oCypNZoMrbmV378Hd5vPWwsHR1p9ZfbMRAFZMbcfZxomM4kwacugBUy0rsw2CzhXj7B4z88jwZJVSWTzKiBbVdisdQpCRgEgM+I6nvDk8WEms32VIWUGaE8apqmMMH+DxpJVVnudGlb2nZvKzTPoUmRHv58O1l2BhwhA393t7WstCMGA==","endDate":1592179200000,"freeKilometers":508,"hasVollkasko":true,"mostSpecificRegionID":81,"numberSeats":2,"price":4481,"startDate":1591920000000},{"ID":"1b993f42-6450-4ed1-a834-7c4a024f10f6","carType":"sports","data":"/QNVpWu5uBIREBD1noR89RSYgnSQX5af7fyrp4u1GCwdKPd+5SMZk3VMg+WQ5rF4scRKfHwGTWYm05ayO+iNHwL5iQD6rIMg5R6q4JsGQpE88ppPnAYHJZl9TvUsZt2gChMxgGbnn+3UlYg75I3GlqdjCODs4sXuGgngV59M2TLMRQF6fKcwLaHaYm27vi4dsvaw1Jvh4T8za9zSHbSq/A4Q77qjZGR+26FtG8sDrkKTNS1y+Z8k20qlH+8/Ud39k3++Q+K1rD30zD1sLCaGWtIF8E+QxniBQynIjKaccVXsvmJUUH9Vh6+YJMqmCmxsHI8WsRKyD4LCZfgW9UcYAQ==","endDate":1592092800000,"freeKilometers":695,"hasVollkasko":true,"mostSpecificRegionID":120,"numberSeats":2,"price":2362,"startDate":1591920000000},{"ID":"f72473c8-b00e-46f2-bd23-6b2111b15a2d","carType":"sports","data":"ue+0jMLw6kVmt4wsyJDVZgWPyu9j+RxNmVC1V7ogi1gaxEas7cLpzmzbT+9dZOmp95+fhVKKkkGNkKwX1nSTJzs6myg5in1FyhnK0WSfZX+Z0hpb3y0UDQEdDmKMOln109nbtb9y+u8Jb2SBi4UVPJ/4I7PwssS+edsvfkdD6aeLVYEKZiglHeFDLaM/bbWmV8Z5JiLHUzvPsj1Q2alKBmmcS7uaD9XozSsY4Y61Hs+OryrM06Apt6eevof4+06sWKwfRMGGt3wylzXJoTANbIESC7WQwi4TLQMgiyCuplxgdQPWohgqswo+YvX+1V0QJoZG7VLERwfi+Rf67d/7rQ==","endDate":1592006400000,"freeKilometers":878,"hasVollkasko":false,"mostSpecificRegionID":60,"numberSeats":5,"price":5154,"startDate":1591833600000},{"ID":"607031c8-7507-40c7-9a24-582d7519e37c","carType":"family","data":"WRM24YCYo+bo/WFsYkog120uRXx61KqvdhxbvggdhTkVMd6awSO6W8lyS3SYh1K1hOyZ532w8aQOpC3lQ62QnJE07AuAovRghmSu7z2cqC9tr6VlCbCIIwQurSn5rfdIYWt/77A+nBhKoN0nOXboZplTK4D44TuDo9XSgZnYzlI3ZPbzWwZakHfWLaqNUYIVvkJMNoWIoj7b11lfY76VMdK78JuXYUci79BrVL/tQV8a4m/g7NGZzhJot4GA1K7TAtk/kynvrpfQ/B+FbBg4AN17fIF0F8mZdSS9JKEW3e+/xec8Cm8widDITRTB50/SJzsgBEQwCowmXS6Oi5YV2A==","endDate":1592092800000,"freeKilometers":236,"hasVollkasko":false,"mostSpecificRegionID":105,"numberSeats":4,"price":4438,"startDate":1591920000000},{"ID":"d77bd5e1-1617-495a-b2c9-d325f7d8e1de","carType":"family","data":"M1lLibnJiAhLpnbD5gMcJGl2ygpPzw6VcnImPxcrWl1rSDwwmImPWeC/ZeSVBuSMHdOzSq4BzWPQdvyUQNjNcIpZMx9IUj2Z8damdU2GA4K5OBqmu8sk3W5BMy2cKf8VNOqXdcZ7k/zP3hgqKX6uXNG/qquIuKkb1MEbcXP1C6Kks2+dnlQjFJbJVL9OBkk/ihMwYYszD+TdNurf4mWIGKM12lOZk4uktzVR2QqmTTkdyBQas7NVMxvgjaEcaq0Ox3+KJkIlhg01tYTehbSOrNXLBHsHv6SeM34mbQgWp5F+XmWQgbLVaDedDvlkOCOevFh3nAe19K+CmslY/4E52g==","endDate":1592179200000,"freeKilometers":102,"hasVollkasko":false,"mostSpecificRegionID":88,"numberSeats":5,"price":2189,"startDate":1591833600000},{"ID":"6562eef4-cd57-468b-affe-07b3a834af62","carType":"sports","data":"3c742E0pUpd6DVoDaEQSGBf0MvIfKPcbBkhi02Oj0hajU1E3ISOKDi8yRs0zoYpTiVFghVIKqRZBstGXD6KFEsedY7jLre28MlEY/RT7GU8vO2GUY03xM9maHeW9xhB3E8dh/n9NxksyHmq/pMzlG9XmHk8pfB5j+1c5Qx69hK8tu9M9Zz9eUTkHgEe4+XL0tlvl1qdZHQAgAntxjTVbzBPDbXhExwzv3xZqPOZumjZSNpY/mm8iS95gxqavEbP67bBLWk/SlAG4QEiQztIkj+m1mTgQ5ez5ifpFnzQAM3JuAinvOyvmdOixaBjxvpbyvWTltwD0gZGVZv8Yr7ipWA==","endDate":1592179200000,"freeKilometers":314,"hasVollkasko":true,"mostSpecificRegionID":108,"numberSeats":4,"price":5408,"startDate":1592006400000},{"ID":"8d499624-78ca-4a4f-aad4-4cee50fb732a","carType":"family","data":"14D1rIwmBYViwyWDdbHCPY62/WatAXdJ4ILExRQD7kTczaHOK5y5s7SgLPCQPYkbhJLUcqpUPYFO5Q+vMMHISBMejNMIwajyySC/wZwYKbk2pvWrE8GHwvzrSGr7ZGtA5l9Za1KaLQvaokjk3kDZRcO/r8Z+75Uqbp6iHOSPO86RozwiAIkcagOIIqm9a0B2y2Kak+zq4tob4bj71yeGaXSLY0B2Q3ro9qPQNpUX9doV4eo5ns7qQWVm3tyZr+u9n5IGF/fNwajTys04fMQKnSufFKCrxEL44irjW8Ra9ImD5zVAB9GKX8fRapY+0lVsTU+dPC8gOyQ4tInv8K04zQ==","endDate":1592092800000,"freeKilometers":530,"hasVollkasko":false,"mostSpecificRegionID":104,"numberSeats":2,"price":2019,"startDate":1591833600000},{"ID":"b7d5e28e-aa11-44d1-a13e-2ea82ad8b144","carType":"small","data":"mbo55qiV8s3wh8Kjkj6rOtG1P2mzT3J3VNqXqBYUb3kMWhf38PcDKzGt389aHmhKzTfGFPX/3hhHKcE6VOeONoEn/plECqNvxZc+/+F4mp3HNqcEZw4CfdSBkrfIVxP9dxiqQrskZ8YDR7VFvwU9EAGR+pE3a5Pb3BZVbmrti4u/uocAznHoDbW1niiEeecDnOGDwg0FhwaAOW2dVUPrj5N/VwD5fEOghigXNcMRDPofWgey0arMl7v6jYk7+OiTvvw+TWtgLIMDuIl+dfuZuGlNRpdtbngUqtkZVSZIl2tIUczRNMtaxhgf903Yzr8DLsHnBaZa/ft7fYUt0uMTXg==","endDate":1592092800000,"freeKilometers":290,"hasVollkasko":false,"mostSpecificRegionID":100,"numberSeats":4,"price":10007,"startDate":1591920000000},{"ID":"8db1cafc-d328-4f0b-8d8f-edf340ed2043","carType":"small","data":"FNhoPrOZ85qvmmOfy8gVU5mOBY4xRtZruEJUoYS30PiDvyQCmzrbqBOVDVi0DZGtaxx638k9Bh/ZdrVwIg2mzoa/u1GRYD5a8WOpO5ImrURw0XEqzpcd0iI3axmcEr24AiDtlutFWnkb4D3rOwJIKg+8ec4pXDstiAVmYVK6sWa6jtjUYDaSlhKMvu163aNS9M50PBbsngHzRdq7rske6XMh7zxFcmsPngOMxmyErCd2jxGU0Wxr+RBSTACsjrsjqbuc02NphuhMzNj+cgsIWHInE9NZlX0t/11OQiETaVgjzZCLETi6G6YibaQOYmCkGdWqVjLTb5yEFAi3WlE2Nw==","endDate":1592179200000,"freeKilometers":745,"hasVollkasko":false,"mostSpecificRegionID":93,"numberSeats":3,"price":1811,"startDate":1591833600000},{"ID":"f63c607a-9140-42c8-bbe7-6e078cc54e75","carType":"family","data":"QdZoDKwHUOM7sIOLTrwCtwkDvVZroHxT35aGFgs8hsMfv4tcJZUxK4Pqs7+YYFzNWMVISTzIEhxAG4Vra6caGfQK620rDLp6hidPmpBjmRidBLbEbkUe9iQahw5zBGXCh3okVyA7sfe47HJXMMMV5FFrB8gZLXc3TT3hXtzBP+qUt8xdOV65oBpU/Yl8jVHM2/dmxUev5udzfJ4iqgmNHSKkTXgDuQxkrWmgepMhM5ENuce9uLjpzALJSVeJkPmZMdyKo353Moiafbea03+bt7Holgnh94q5A2O+/7ftlpqgLSMExYX5qwWn90R2f9SywBAi9K+yDRxm9+8m+01BPg==","endDate":1592179200000,"freeKilometers":133,"hasVollkasko":false,"mostSpecificRegionID":120,"numberSeats":5,"price":7335,"startDate":1591833600000}]}`
 
-	TestPostOffers(t)
+// TestGetOffersAdvanced tests the GET /api/offers endpoint with pagination and facets
+func TestGetOffersAdvanced(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, app *fiber.App) {
+		postOffers(t, app, []byte(testOffers))
+
+		// Five offers last at least three days, the second page holds the third and fourth cheapest
+		response := getOffers(t, app, "regionID=0&timeRangeStart=1591833600000&timeRangeEnd=1592179200000&numberDays=3&sortOrder=price-asc&page=1&pageSize=2&priceRangeWidth=5000&minFreeKilometerWidth=500")
+
+		assert.Equal(t, []string{"d77bd5e1-1617-495a-b2c9-d325f7d8e1de", "68ed0a29-a7ae-42b4-bdfd-2f35462828ca"}, offerIDs(response.Offers))
+		assert.Equal(t, []models.PriceRange{{Start: 0, End: 5000, Count: 4}, {Start: 5000, End: 10000, Count: 1}}, response.PriceRanges)
+		assert.Equal(t, models.CarTypeCounts{Small: 1, Sports: 1, Family: 3}, response.CarTypeCounts)
+		assert.Equal(t, []models.SeatsCount{{NumberSeats: 2, Count: 2}, {NumberSeats: 3, Count: 1}, {NumberSeats: 5, Count: 2}}, response.SeatsCount)
+		assert.Equal(t, []models.FreeKilometerRange{{Start: 0, End: 500, Count: 2}, {Start: 500, End: 1000, Count: 3}}, response.FreeKilometerRange)
+		assert.Equal(t, models.VollkaskoCount{TrueCount: 1, FalseCount: 4}, response.VollkaskoCount)
+	})
+}
 
-	// Führe eine GET-Anfrage an /api/offers durch
-	// /api/offers?minFreeKilometerWidth=50&numberDays=4&page=0&pageSize=100&priceRangeWidth=10&regionID=0&sortOrder=price-asc&timeRangeEnd=1673568000000&timeRangeStart=1673222400000
-	req := httptest.NewRequest("GET", "/api/offers?minFreeKilometerWidth=50&numberDays=1&page=0&pageSize=100&priceRangeWidth=10&regionID=37&sortOrder=price-asc&timeRangeEnd=1692179200000&timeRangeStart=1491920000000&onlyVollkasko=true", nil)
-	resp, err := app.Test(req)
+func TestGetOffers2(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, app *fiber.App) {
+		postOffers(t, app, []byte(testOffers))
 
-	// Überprüfen, ob keine Fehler beim Testen aufgetreten sind
-	assert.NoError(t, err)
+		// The only offer in Old Town has no Vollkasko, the Vollkasko facet still counts it
+		response := getOffers(t, app, "minFreeKilometerWidth=50&numberDays=1&page=0&pageSize=100&priceRangeWidth=10&regionID=37&sortOrder=price-asc&timeRangeEnd=1692179200000&timeRangeStart=1491920000000&onlyVollkasko=true")
 
-	// Überprüfen, ob der Statuscode = 200 ist
-	assert.Equal(t, 200, resp.StatusCode)
+		assert.Empty(t, response.Offers)
+		assert.Equal(t, models.VollkaskoCount{TrueCount: 0, FalseCount: 1}, response.VollkaskoCount)
+	})
 }
 
 func TestPostOffers(t *testing.T) {
-	/*
-		// Setup der App
-		app := setupApp()
-
-		// Erstelle ein Beispielangebot
-		offer := map[string]string{"ID": "3", "Data": "Sample Offer 3"}
-		offerJSON, _ := json.Marshal(offer)
-
-		// Führe eine POST-Anfrage an /api/offers durch
-		req := httptest.NewRequest("POST", "/api/offers", bytes.NewReader(offerJSON))
-		req.Header.Set("Content-Type", "application/json")
-		resp, err := app.Test(req)
-
-		// Überprüfen der Antwort
-		assert.NoError(t, err)
-		assert.Equal(t, 201, resp.StatusCode)
-
-		var createdOffer map[string]string
-		err = json.NewDecoder(resp.Body).Decode(&createdOffer)
-		assert.NoError(t, err)
-		assert.Equal(t, "3", createdOffer["ID"])*/
-
-	// Setup der App
-	app := setupApp()
-
-	// JSON-Daten als String
-	offerJSON := `{"offers":[{"ID":"68ed0a29-a7ae-42b4-bdfd-2f35462828ca","carType":"sports","data":"9Wm0fKJQxnqV+1R8Eie8g2D2hXjsX3H1Yri3S46Ev+Sil/L1sbn6UeaKQLxif+IlR1Srgx12Xpyaqx+gS+T4UnnSxyNKnpK49Jdag6i2BqmdIt7c3LZz891HVCMtrpTqevN5HaiRXy5yLGdix8PMAdxfj7qY5wgR1EXcn5oCypNZoMrbmV378Hd5vPWwsHR1p9ZfbMRAFZMbcfZxomM4kwacugBUy0rsw2CzhXj7B4z88jwZJVSWTzKiBbVdisdQpCRgEgM+I6nvDk8WEms32VIWUGaE8apqmMMH+DxpJVVnudGlb2nZvKzTPoUmRHv58O1l2BhwhA393t7WstCMGA==","endDate":1592179200000,"freeKilometers":508,"hasVollkasko":true,"mostSpecificRegionID":81,"numberSeats":2,"price":4481,"startDate":1591920000000},{"ID":"1b993f42-6450-4ed1-a834-7c4a024f10f6","carType":"sports","data":"/QNVpWu5uBIREBD1noR89RSYgnSQX5af7fyrp4u1GCwdKPd+5SMZk3VMg+WQ5rF4scRKfHwGTWYm05ayO+iNHwL5iQD6rIMg5R6q4JsGQpE88ppPnAYHJZl9TvUsZt2gChMxgGbnn+3UlYg75I3GlqdjCODs4sXuGgngV59M2TLMRQF6fKcwLaHaYm27vi4dsvaw1Jvh4T8za9zSHbSq/A4Q77qjZGR+26FtG8sDrkKTNS1y+Z8k20qlH+8/Ud39k3++Q+K1rD30zD1sLCaGWtIF8E+QxniBQynIjKaccVXsvmJUUH9Vh6+YJMqmCmxsHI8WsRKyD4LCZfgW9UcYAQ==","endDate":1592092800000,"freeKilometers":695,"hasVollkasko":true,"mostSpecificRegionID":120,"numberSeats":2,"price":2362,"startDate":1591920000000},{"ID":"f72473c8-b00e-46f2-bd23-6b2111b15a2d","carType":"sports","data":"ue+0jMLw6kVmt4wsyJDVZgWPyu9j+RxNmVC1V7ogi1gaxEas7cLpzmzbT+9dZOmp95+fhVKKkkGNkKwX1nSTJzs6myg5in1FyhnK0WSfZX+Z0hpb3y0UDQEdDmKMOln109nbtb9y+u8Jb2SBi4UVPJ/4I7PwssS+edsvfkdD6aeLVYEKZiglHeFDLaM/bbWmV8Z5JiLHUzvPsj1Q2alKBmmcS7uaD9XozSsY4Y61Hs+OryrM06Apt6eevof4+06sWKwfRMGGt3wylzXJoTANbIESC7WQwi4TLQMgiyCuplxgdQPWohgqswo+YvX+1V0QJoZG7VLERwfi+Rf67d/7rQ==","endDate":1592006400000,"freeKilometers":878,"hasVollkasko":false,"mostSpecificRegionID":60,"numberSeats":5,"price":5154,"startDate":1591833600000},{"ID":"607031c8-7507-40c7-9a24-582d7519e37c","carType":"family","data":"WRM24YCYo+bo/WFsYkog120uRXx61KqvdhxbvggdhTkVMd6awSO6W8lyS3SYh1K1hOyZ532w8aQOpC3lQ62QnJE07AuAovRghmSu7z2cqC9tr6VlCbCIIwQurSn5rfdIYWt/77A+nBhKoN0nOXboZplTK4D44TuDo9XSgZnYzlI3ZPbzWwZakHfWLaqNUYIVvkJMNoWIoj7b11lfY76VMdK78JuXYUci79BrVL/tQV8a4m/g7NGZzhJot4GA1K7TAtk/kynvrpfQ/B+FbBg4AN17fIF0F8mZdSS9JKEW3e+/xec8Cm8widDITRTB50/SJzsgBEQwCowmXS6Oi5YV2A==","endDate":1592092800000,"freeKilometers":236,"hasVollkasko":false,"mostSpecificRegionID":105,"numberSeats":4,"price":4438,"startDate":1591920000000},{"ID":"d77bd5e1-1617-495a-b2c9-d325f7d8e1de","carType":"family","data":"M1lLibnJiAhLpnbD5gMcJGl2ygpPzw6VcnImPxcrWl1rSDwwmImPWeC/ZeSVBuSMHdOzSq4BzWPQdvyUQNjNcIpZMx9IUj2Z8damdU2GA4K5OBqmu8sk3W5BMy2cKf8VNOqXdcZ7k/zP3hgqKX6uXNG/qquIuKkb1MEbcXP1C6Kks2+dnlQjFJbJVL9OBkk/ihMwYYszD+TdNurf4mWIGKM12lOZk4uktzVR2QqmTTkdyBQas7NVMxvgjaEcaq0Ox3+KJkIlhg01tYTehbSOrNXLBHsHv6SeM34mbQgWp5F+XmWQgbLVaDedDvlkOCOevFh3nAe19K+CmslY/4E52g==","endDate":1592179200000,"freeKilometers":102,"hasVollkasko":false,"mostSpecificRegionID":88,"numberSeats":5,"price":2189,"startDate":1591833600000},{"ID":"6562eef4-cd57-468b-affe-07b3a834af62","carType":"sports","data":"3c742E0pUpd6DVoDaEQSGBf0MvIfKPcbBkhi02Oj0hajU1E3ISOKDi8yRs0zoYpTiVFghVIKqRZBstGXD6KFEsedY7jLre28MlEY/RT7GU8vO2GUY03xM9maHeW9xhB3E8dh/n9NxksyHmq/pMzlG9XmHk8pfB5j+1c5Qx69hK8tu9M9Zz9eUTkHgEe4+XL0tlvl1qdZHQAgAntxjTVbzBPDbXhExwzv3xZqPOZumjZSNpY/mm8iS95gxqavEbP67bBLWk/SlAG4QEiQztIkj+m1mTgQ5ez5ifpFnzQAM3JuAinvOyvmdOixaBjxvpbyvWTltwD0gZGVZv8Yr7ipWA==","endDate":1592179200000,"freeKilometers":314,"hasVollkasko":true,"mostSpecificRegionID":108,"numberSeats":4,"price":5408,"startDate":1592006400000},{"ID":"8d499624-78ca-4a4f-aad4-4cee50fb732a","carType":"family","data":"14D1rIwmBYViwyWDdbHCPY62/WatAXdJ4ILExRQD7kTczaHOK5y5s7SgLPCQPYkbhJLUcqpUPYFO5Q+vMMHISBMejNMIwajyySC/wZwYKbk2pvWrE8GHwvzrSGr7ZGtA5l9Za1KaLQvaokjk3kDZRcO/r8Z+75Uqbp6iHOSPO86RozwiAIkcagOIIqm9a0B2y2Kak+zq4tob4bj71yeGaXSLY0B2Q3ro9qPQNpUX9doV4eo5ns7qQWVm3tyZr+u9n5IGF/fNwajTys04fMQKnSufFKCrxEL44irjW8Ra9ImD5zVAB9GKX8fRapY+0lVsTU+dPC8gOyQ4tInv8K04zQ==","endDate":1592092800000,"freeKilometers":530,"hasVollkasko":false,"mostSpecificRegionID":104,"numberSeats":2,"price":2019,"startDate":1591833600000},{"ID":"b7d5e28e-aa11-44d1-a13e-2ea82ad8b144","carType":"small","data":"mbo55qiV8s3wh8Kjkj6rOtG1P2mzT3J3VNqXqBYUb3kMWhf38PcDKzGt389aHmhKzTfGFPX/3hhHKcE6VOeONoEn/plECqNvxZc+/+F4mp3HNqcEZw4CfdSBkrfIVxP9dxiqQrskZ8YDR7VFvwU9EAGR+pE3a5Pb3BZVbmrti4u/uocAznHoDbW1niiEeecDnOGDwg0FhwaAOW2dVUPrj5N/VwD5fEOghigXNcMRDPofWgey0arMl7v6jYk7+OiTvvw+TWtgLIMDuIl+dfuZuGlNRpdtbngUqtkZVSZIl2tIUczRNMtaxhgf903Yzr8DLsHnBaZa/ft7fYUt0uMTXg==","endDate":1592092800000,"freeKilometers":290,"hasVollkasko":false,"mostSpecificRegionID":100,"numberSeats":4,"price":10007,"startDate":1591920000000},{"ID":"8db1cafc-d328-4f0b-8d8f-edf340ed2043","carType":"small","data":"FNhoPrOZ85qvmmOfy8gVU5mOBY4xRtZruEJUoYS30PiDvyQCmzrbqBOVDVi0DZGtaxx638k9Bh/ZdrVwIg2mzoa/u1GRYD5a8WOpO5ImrURw0XEqzpcd0iI3axmcEr24AiDtlutFWnkb4D3rOwJIKg+8ec4pXDstiAVmYVK6sWa6jtjUYDaSlhKMvu163aNS9M50PBbsngHzRdq7rske6XMh7zxFcmsPngOMxmyErCd2jxGU0Wxr+RBSTACsjrsjqbuc02NphuhMzNj+cgsIWHInE9NZlX0t/11OQiETaVgjzZCLETi6G6YibaQOYmCkGdWqVjLTb5yEFAi3WlE2Nw==","endDate":1592179200000,"freeKilometers":745,"hasVollkasko":false,"mostSpecificRegionID":93,"numberSeats":3,"price":1811,"startDate":1591833600000},{"ID":"f63c607a-9140-42c8-bbe7-6e078cc54e75","carType":"family","data":"QdZoDKwHUOM7sIOLTrwCtwkDvVZroHxT35aGFgs8hsMfv4tcJZUxK4Pqs7+YYFzNWMVISTzIEhxAG4Vra6caGfQK620rDLp6hidPmpBjmRidBLbEbkUe9iQahw5zBGXCh3okVyA7sfe47HJXMMMV5FFrB8gZLXc3TT3hXtzBP+qUt8xdOV65oBpU/Yl8jVHM2/dmxUev5udzfJ4iqgmNHSKkTXgDuQxkrWmgepMhM5ENuce9uLjpzALJSVeJkPmZMdyKo353Moiafbea03+bt7Holgnh94q5A2O+/7ftlpqgLSMExYX5qwWn90R2f9SywBAi9K+yDRxm9+8m+01BPg==","endDate":1592179200000,"freeKilometers":133,"hasVollkasko":false,"mostSpecificRegionID":120,"numberSeats":5,"price":7335,"startDate":1591833600000}]}`
-	// Führe eine POST-Anfrage an /api/offers durch
-	req := httptest.NewRequest("POST", "/api/offers", bytes.NewReader([]byte(offerJSON)))
-	req.Header.Set("Content-Type", "application/json")
-	resp, err := app.Test(req)
-
-	// Überprüfen, ob keine Fehler beim Testen aufgetreten sind
-	assert.NoError(t, err)
-
-	// Überprüfen, ob der Statuscode = 200 ist
-	assert.Equal(t, resp.StatusCode, 200)
+	forEachBackend(t, func(t *testing.T, app *fiber.App) {
+		result := postOffers(t, app, []byte(testOffers))
+		assert.Equal(t, 10, result.Inserted)
+		assert.Empty(t, result.Rejected)
+	})
 }
 
-func TestPostPerf(t *testing.T) {
-	// Setup der App
-	app := setupApp()
-
-	numOffers := 1000
-
-	// Erzeuge die JSON-Daten für die POST-Anfrage
-	offers := []byte(`{"offers": [`)
-
-	for i := 0; i < numOffers; i++ {
-		// Erzeuge eine zufällige UUID für jedes Angebot
-		id := uuid.New().String()
-
-		// Baue das Angebot mit der zufälligen UUID
-		offer := fmt.Sprintf(`
-		{
-			"ID": "%s",
-			"data": "string",
-			"mostSpecificRegionID": 5,
-			"startDate": 1732104000000,
-			"endDate": 1732449600000,
-			"numberSeats": 5,
-			"price": 10000,
-			"carType": "luxury",
-			"hasVollkasko": true,
-			"freeKilometers": 120
-		}`, id)
-
-		// Füge das Angebot zur JSON-Datenstruktur hinzu
-		if i > 0 {
-			offers = append(offers, []byte(",")...)
+func TestPostOffersRepeatedIDInBatch(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, app *fiber.App) {
+		var batch struct {
+			Offers []map[string]interface{} `json:"offers"`
+		}
+		assert.NoError(t, json.Unmarshal(generateOffers(2), &batch))
+		batch.Offers[1]["ID"] = batch.Offers[0]["ID"]
+		body, _ := json.Marshal(batch)
+
+		// The repeated ID is rejected per item and never reaches the repository as an update
+		result := postOffers(t, app, body)
+		assert.Equal(t, models.InsertResult{Inserted: 1}, result.InsertResult)
+		if assert.Len(t, result.Rejected, 1) {
+			assert.Equal(t, 1, result.Rejected[0].Index)
 		}
-		offers = append(offers, []byte(offer)...)
-	}
-
-	// Beende das Array und das JSON-Objekt
-	offers = append(offers, []byte(`]}`)...)
-
-	// Führe eine POST-Anfrage an /api/offers durch
-	req := httptest.NewRequest("POST", "/api/offers", bytes.NewReader(offers))
-	req.Header.Set("Content-Type", "application/json")
-	resp, err := app.Test(req)
 
-	// Überprüfen, ob keine Fehler beim Testen aufgetreten sind
-	assert.NoError(t, err)
+		response := getOffers(t, app, "regionID=58&timeRangeStart=0&timeRangeEnd=1800000000000&numberDays=1&sortOrder=price-asc&page=0&pageSize=10&priceRangeWidth=1000&minFreeKilometerWidth=100")
+		assert.Len(t, response.Offers, 1)
+	})
+}
 
-	// Überprüfen, ob der Statuscode = 200 ist
-	assert.Equal(t, resp.StatusCode, 200)
+func TestPostPerf(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, app *fiber.App) {
+		result := postOffers(t, app, generateOffers(1000))
+		assert.Equal(t, 1000, result.Inserted)
+	})
 }
 
 func TestPostPerfConcurrency(t *testing.T) {
-	// Setup der App
-	app := setupApp()
-
 	// Anzahl der zu generierenden Angebote
 	numOffersPerBatch := 1000
 
@@ -228,63 +203,40 @@ func TestPostPerfConcurrency(t *testing.T) {
 	// Anzahl der Batches
 	numBatches := 10 //100
 
-	// Warteschleife für parallele Tests
-	var wg sync.WaitGroup
-
-	// Führe parallele Tests aus
-	for n := 0; n < numBatches; n++ {
-
-		for i := 0; i < concurrency; i++ {
-			wg.Add(1)
-			go func(i int) {
-				defer wg.Done()
-
-				// Erzeuge die JSON-Daten für die POST-Anfrage
-				offers := []byte(`{"offers": [`)
-
-				for j := 0; j < numOffersPerBatch/concurrency; j++ {
-					// Erzeuge eine zufällige UUID für jedes Angebot
-					id := uuid.New().String()
-
-					// Baue das Angebot mit der zufälligen UUID
-					offer := fmt.Sprintf(`
-				{
-					"ID": "%s",
-					"data": "string",
-					"mostSpecificRegionID": 5,
-					"startDate": 1732104000000,
-					"endDate": 1732449600000,
-					"numberSeats": 5,
-					"price": 10000,
-					"carType": "luxury",
-					"hasVollkasko": true,
-					"freeKilometers": 120
-				}`, id)
-
-					// Füge das Angebot zur JSON-Datenstruktur hinzu
-					if j > 0 {
-						offers = append(offers, []byte(",")...)
-					}
-					offers = append(offers, []byte(offer)...)
-				}
+	forEachBackend(t, func(t *testing.T, app *fiber.App) {
+		// Warteschleife für parallele Tests
+		var wg sync.WaitGroup
 
-				// Beende das Array und das JSON-Objekt
-				offers = append(offers, []byte(`]}`)...)
+		for n := 0; n < numBatches; n++ {
+			for i := 0; i < concurrency; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
 
-				// Führe eine POST-Anfrage an /api/offers durch
-				req := httptest.NewRequest("POST", "/api/offers", bytes.NewReader(offers))
-				req.Header.Set("Content-Type", "application/json")
-				resp, err := app.Test(req)
+					req := httptest.NewRequest(http.MethodPost, "/api/offers", bytes.NewReader(generateOffers(numOffersPerBatch/concurrency)))
+					req.Header.Set("Content-Type", "application/json")
+					resp, err := app.Test(req, -1)
 
-				// Überprüfen, ob keine Fehler beim Testen aufgetreten sind
-				assert.NoError(t, err)
+					// t.Fatalf darf nicht aus einer Goroutine aufgerufen werden
+					if assert.NoError(t, err) {
+						assert.Equal(t, http.StatusOK, resp.StatusCode)
+					}
+				}()
+			}
 
-				// Überprüfen, ob der Statuscode = 200 ist
-				assert.Equal(t, resp.StatusCode, 200)
-			}(i)
+			// Warten, bis alle Goroutinen abgeschlossen sind
+			wg.Wait()
 		}
 
-		// Warten, bis alle Goroutinen abgeschlossen sind
-		wg.Wait()
-	}
+		response := getOffers(t, app, "regionID=1&timeRangeStart=0&timeRangeEnd=1732406400000&numberDays=1&sortOrder=price-asc&page=0&pageSize=1&priceRangeWidth=100000&minFreeKilometerWidth=1000")
+		assert.Equal(t, models.CarTypeCounts{Luxury: numBatches * concurrency * (numOffersPerBatch / concurrency)}, response.CarTypeCounts)
+	})
+}
+
+// Batches above PostgreSQL's bind parameter limit (65535 / 10 columns) have to be split
+func TestPostOffersAboveParameterLimit(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, app *fiber.App) {
+		result := postOffers(t, app, generateOffers(7000))
+		assert.Equal(t, 7000, result.Inserted)
+	})
 }
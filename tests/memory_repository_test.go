@@ -0,0 +1,222 @@
+package tests
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"server/internal/database"
+	"server/internal/models"
+	"server/internal/regions"
+	"server/internal/repository"
+	"testing"
+)
+
+// Small region tree: 0 -> (1 -> (3, 4), 2 -> (5))
+func testRegionTree() database.Region {
+	return database.Region{ID: 0, Name: "Root", Subregions: []database.Region{
+		{ID: 1, Name: "A", Subregions: []database.Region{
+			{ID: 3, Name: "A1"},
+			{ID: 4, Name: "A2"},
+		}},
+		{ID: 2, Name: "B", Subregions: []database.Region{
+			{ID: 5, Name: "B1"},
+		}},
+	}}
+}
+
+func TestRegionIndexContains(t *testing.T) {
+	index := regions.NewIndex(testRegionTree())
+
+	assert.Equal(t, 6, index.Len())
+	assert.True(t, index.Contains(0, 5))
+	assert.True(t, index.Contains(1, 4))
+	assert.True(t, index.Contains(1, 1))
+	assert.False(t, index.Contains(1, 5))
+	assert.False(t, index.Contains(3, 1))
+	assert.False(t, index.Contains(42, 1))
+
+	assert.True(t, index.IsLeaf(3))
+	assert.False(t, index.IsLeaf(2))
+}
+
+func TestMemoryRepositoryGetOffers(t *testing.T) {
+	repo := repository.NewOfferMemoryRepository(regions.NewIndex(testRegionTree()))
+	day := int64(24 * 3600 * 1000)
+
+	_, err := repo.CreateOffers(context.Background(), []models.Offer{
+		{ID: "c", MostSpecificRegionID: 3, StartDate: 0, EndDate: 2 * day, Price: 300},
+		{ID: "a", MostSpecificRegionID: 4, StartDate: 0, EndDate: 2 * day, Price: 100},
+		{ID: "b", MostSpecificRegionID: 5, StartDate: 0, EndDate: 2 * day, Price: 200},
+		{ID: "d", MostSpecificRegionID: 3, StartDate: 0, EndDate: 1 * day, Price: 50},
+		{ID: "e", MostSpecificRegionID: 4, StartDate: 0, EndDate: 2 * day, Price: 150, NumberSeats: 7, CarType: "family"},
+	}, models.ConflictReject)
+	assert.NoError(t, err)
+
+	params := models.OfferFilterParams{
+		RegionID:              1,
+		TimeRangeStart:        0,
+		TimeRangeEnd:          int(10 * day),
+		NumberDays:            2,
+		SortOrder:             "price-asc",
+		Page:                  0,
+		PageSize:              10,
+		PriceRangeWidth:       100,
+		MinFreeKilometerWidth: 100,
+	}
+	response, err := repo.GetOffers(context.Background(), params)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "e", "c"}, offerIDs(response.Offers))
+
+	params.RegionID = 0
+	params.SortOrder = "price-desc"
+	response, err = repo.GetOffers(context.Background(), params)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"c", "b", "e", "a"}, offerIDs(response.Offers))
+
+	// Facets are computed over all matches, each ignoring its own filter
+	minNumberSeats := 5
+	params.MinNumberSeats = &minNumberSeats
+	params.PageSize = 1
+	response, err = repo.GetOffers(context.Background(), params)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"e"}, offerIDs(response.Offers))
+	assert.Equal(t, []models.SeatsCount{{NumberSeats: 0, Count: 3}, {NumberSeats: 7, Count: 1}}, response.SeatsCount)
+	assert.Equal(t, []models.PriceRange{{Start: 100, End: 200, Count: 1}}, response.PriceRanges)
+	assert.Equal(t, models.CarTypeCounts{Family: 1}, response.CarTypeCounts)
+
+	// Duplicate IDs are rejected like a primary key violation
+	_, err = repo.CreateOffers(context.Background(), []models.Offer{{ID: "a", MostSpecificRegionID: 3}}, models.ConflictReject)
+	assert.ErrorIs(t, err, repository.ErrDuplicateOffer)
+}
+
+func offerIDs(offers []models.ResponseOffer) []string {
+	ids := make([]string, 0, len(offers))
+	for _, offer := range offers {
+		ids = append(ids, offer.ID)
+	}
+	return ids
+}
+
+func TestMemoryRepositoryKeysetPagination(t *testing.T) {
+	repo := repository.NewOfferMemoryRepository(regions.NewIndex(testRegionTree()))
+	day := int64(24 * 3600 * 1000)
+
+	_, err := repo.CreateOffers(context.Background(), []models.Offer{
+		{ID: "a", MostSpecificRegionID: 3, EndDate: day, Price: 100},
+		{ID: "b", MostSpecificRegionID: 4, EndDate: day, Price: 100},
+		{ID: "c", MostSpecificRegionID: 5, EndDate: day, Price: 200},
+		{ID: "d", MostSpecificRegionID: 3, EndDate: day, Price: 300},
+		{ID: "e", MostSpecificRegionID: 4, EndDate: day, Price: 50},
+	}, models.ConflictReject)
+	assert.NoError(t, err)
+
+	for sortOrder, expected := range map[string][]string{
+		"price-asc":  {"e", "a", "b", "c", "d"},
+		"price-desc": {"d", "c", "a", "b", "e"},
+	} {
+		params := models.OfferFilterParams{
+			RegionID:              0,
+			TimeRangeEnd:          int(day),
+			SortOrder:             sortOrder,
+			Page:                  5, // ignored in keyset mode
+			PageSize:              2,
+			PriceRangeWidth:       100,
+			MinFreeKilometerWidth: 100,
+			KeysetPagination:      true,
+		}
+
+		var ids []string
+		for pages := 0; pages < 10; pages++ {
+			response, err := repo.GetOffers(context.Background(), params)
+			assert.NoError(t, err)
+			ids = append(ids, offerIDs(response.Offers)...)
+			if response.NextCursor == "" {
+				break
+			}
+
+			after, err := models.DecodeOfferCursor(response.NextCursor)
+			assert.NoError(t, err)
+			params.After = &after
+		}
+		assert.Equal(t, expected, ids, sortOrder)
+	}
+}
+
+func TestMemoryRepositoryConflictModes(t *testing.T) {
+	repo := repository.NewOfferMemoryRepository(regions.NewIndex(testRegionTree()))
+	ctx := context.Background()
+
+	result, err := repo.CreateOffers(ctx, []models.Offer{
+		{ID: "a", MostSpecificRegionID: 3, EndDate: 1, Price: 100},
+		{ID: "b", MostSpecificRegionID: 4, EndDate: 1, Price: 200},
+	}, models.ConflictReject)
+	assert.NoError(t, err)
+	assert.Equal(t, models.InsertResult{Inserted: 2}, result)
+
+	retry := []models.Offer{
+		{ID: "b", MostSpecificRegionID: 5, EndDate: 1, Price: 250},
+		{ID: "c", MostSpecificRegionID: 5, EndDate: 1, Price: 300},
+	}
+
+	_, err = repo.CreateOffers(ctx, retry, models.ConflictReject)
+	assert.ErrorIs(t, err, repository.ErrDuplicateOffer)
+
+	// An ID repeated within the batch is a conflict as well, like the unique violation in PostgreSQL
+	_, err = repo.CreateOffers(ctx, []models.Offer{retry[1], retry[1]}, models.ConflictReject)
+	assert.ErrorIs(t, err, repository.ErrDuplicateOffer)
+
+	result, err = repo.CreateOffers(ctx, retry[:1], models.ConflictIgnore)
+	assert.NoError(t, err)
+	assert.Equal(t, models.InsertResult{Skipped: 1}, result)
+
+	result, err = repo.CreateOffers(ctx, retry, models.ConflictUpdate)
+	assert.NoError(t, err)
+	assert.Equal(t, models.InsertResult{Inserted: 1, Updated: 1}, result)
+
+	// The updated offer moved to region 5
+	response, err := repo.GetOffers(ctx, models.OfferFilterParams{
+		RegionID:              2,
+		TimeRangeEnd:          1,
+		SortOrder:             "price-asc",
+		PageSize:              10,
+		PriceRangeWidth:       100,
+		MinFreeKilometerWidth: 100,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"b", "c"}, offerIDs(response.Offers))
+}
+
+func TestMemoryRepositorySortOrders(t *testing.T) {
+	repo := repository.NewOfferMemoryRepository(regions.NewIndex(testRegionTree()))
+	day := int64(24 * 3600 * 1000)
+
+	_, err := repo.CreateOffers(context.Background(), []models.Offer{
+		{ID: "a", MostSpecificRegionID: 3, StartDate: 2 * day, EndDate: 3 * day, Price: 100, FreeKilometers: 500, NumberSeats: 2},
+		{ID: "b", MostSpecificRegionID: 4, StartDate: 0, EndDate: 3 * day, Price: 300, FreeKilometers: 100, NumberSeats: 7},
+		{ID: "c", MostSpecificRegionID: 5, StartDate: 1 * day, EndDate: 3 * day, Price: 200, FreeKilometers: 500, NumberSeats: 4},
+		{ID: "d", MostSpecificRegionID: 5, StartDate: 1 * day, EndDate: 2 * day, Price: 100, FreeKilometers: 0, NumberSeats: 4},
+	}, models.ConflictReject)
+	assert.NoError(t, err)
+
+	for sortOrder, expected := range map[string][]string{
+		"price-asc":           {"a", "d", "c", "b"},
+		"price-desc":          {"b", "c", "a", "d"},
+		"freeKilometers-desc": {"a", "c", "b", "d"},
+		"numberSeats-desc":    {"b", "c", "d", "a"},
+		"duration-asc":        {"a", "d", "c", "b"},
+		"startDate-asc":       {"b", "c", "d", "a"},
+	} {
+		response, err := repo.GetOffers(context.Background(), models.OfferFilterParams{
+			RegionID:              0,
+			TimeRangeEnd:          int(10 * day),
+			SortOrder:             sortOrder,
+			PageSize:              10,
+			PriceRangeWidth:       100,
+			MinFreeKilometerWidth: 100,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, expected, offerIDs(response.Offers), sortOrder)
+	}
+
+	_, err = repo.GetOffers(context.Background(), models.OfferFilterParams{SortOrder: "price", PageSize: 10, PriceRangeWidth: 1, MinFreeKilometerWidth: 1})
+	assert.Error(t, err)
+}
@@ -0,0 +1,47 @@
+package tests
+
+import (
+	"bytes"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"server/internal/metrics"
+	"testing"
+)
+
+func TestMetrics(t *testing.T) {
+	app := setupMemoryApp()
+
+	rejected := testutil.ToFloat64(metrics.OffersRejected)
+	inserted := testutil.ToFloat64(metrics.OffersIngested.WithLabelValues("inserted"))
+	searches := testutil.CollectAndCount(metrics.SearchRowsScanned)
+
+	body := `{"offers":[
+		{"ID":"68ed0a29-a7ae-42b4-bdfd-2f35462828ca","data":"x","mostSpecificRegionID":3,"startDate":1591920000000,"endDate":1592179200000,"numberSeats":2,"price":4481,"carType":"sports","hasVollkasko":true,"freeKilometers":508},
+		{"ID":"no-uuid","data":"x","mostSpecificRegionID":3,"startDate":1591920000000,"endDate":1592179200000,"numberSeats":2,"price":4481,"carType":"sports","hasVollkasko":true,"freeKilometers":508}
+	]}`
+	req := httptest.NewRequest(http.MethodPost, "/api/offers", bytes.NewReader([]byte(body)))
+	req.Header.Set("Content-Type", "application/json")
+	_, err := app.Test(req)
+	assert.NoError(t, err)
+
+	_, err = app.Test(httptest.NewRequest(http.MethodGet, "/api/offers?regionID=0&timeRangeStart=0&timeRangeEnd=1692179200000&numberDays=1&sortOrder=price-asc&page=0&pageSize=10&priceRangeWidth=10&minFreeKilometerWidth=10", nil))
+	assert.NoError(t, err)
+
+	assert.Equal(t, rejected+1, testutil.ToFloat64(metrics.OffersRejected))
+	assert.Equal(t, inserted+1, testutil.ToFloat64(metrics.OffersIngested.WithLabelValues("inserted")))
+	assert.Equal(t, searches, testutil.CollectAndCount(metrics.SearchRowsScanned))
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	exposition, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.Contains(t, string(exposition), `http_request_duration_seconds_count{method="GET",route="/api/offers",status="200"}`)
+	assert.Contains(t, string(exposition), `http_request_duration_seconds_count{method="POST",route="/api/offers",status="200"}`)
+	assert.Contains(t, string(exposition), "offer_search_rows_scanned_count")
+	assert.Contains(t, string(exposition), "offers_stored 1")
+}
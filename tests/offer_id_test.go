@@ -0,0 +1,144 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/gofiber/fiber/v2"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"server/internal/metrics"
+	"server/internal/models"
+	"testing"
+)
+
+// sendJSON sends a request with a JSON body and returns the response
+func sendJSON(t *testing.T, app *fiber.App, method, path, body string) *http.Response {
+	t.Helper()
+	req := httptest.NewRequest(method, path, bytes.NewReader([]byte(body)))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatalf("Error during %s request: %v", method, err)
+	}
+	return resp
+}
+
+func TestOfferByID(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, app *fiber.App) {
+		postOffers(t, app, []byte(testOffers))
+		const id = "68ed0a29-a7ae-42b4-bdfd-2f35462828ca"
+
+		// Upper-case IDs are normalized like on ingest
+		resp := sendJSON(t, app, http.MethodGet, "/api/offers/68ED0A29-A7AE-42B4-BDFD-2F35462828CA", "")
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		var offer models.Offer
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&offer))
+		assert.Equal(t, id, offer.ID)
+		assert.Equal(t, 81, offer.MostSpecificRegionID)
+		assert.Equal(t, 4481, offer.Price)
+		assert.True(t, offer.OnlyVollkasko)
+
+		// Only the given fields change, the offer moves to its new region
+		resp = sendJSON(t, app, http.MethodPatch, "/api/offers/"+id, `{"price":999,"mostSpecificRegionID":120}`)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&offer))
+		assert.Equal(t, 999, offer.Price)
+		assert.Equal(t, 120, offer.MostSpecificRegionID)
+		assert.Equal(t, "sports", offer.CarType)
+
+		response := getOffers(t, app, "regionID=18&timeRangeStart=0&timeRangeEnd=1692179200000&numberDays=1&sortOrder=price-asc&page=0&pageSize=10&priceRangeWidth=1000&minFreeKilometerWidth=1000")
+		assert.Equal(t, []string{id, "1b993f42-6450-4ed1-a834-7c4a024f10f6", "f63c607a-9140-42c8-bbe7-6e078cc54e75"}, offerIDs(response.Offers))
+
+		// The patched offer has to satisfy the ingest rules, nothing is changed otherwise
+		resp = sendJSON(t, app, http.MethodPatch, "/api/offers/"+id, `{"price":500,"endDate":1591920000000}`)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		assert.Equal(t, map[string]string{"endDate": "must be after startDate"}, decodeFieldErrors(t, resp))
+
+		resp = sendJSON(t, app, http.MethodPatch, "/api/offers/"+id, `{"ID":"f63c607a-9140-42c8-bbe7-6e078cc54e75"}`)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		assert.Equal(t, map[string]string{"ID": "cannot be changed"}, decodeFieldErrors(t, resp))
+
+		resp = sendJSON(t, app, http.MethodGet, "/api/offers/"+id, "")
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&offer))
+		assert.Equal(t, 999, offer.Price)
+
+		// Delete
+		withdrawn := testutil.ToFloat64(metrics.OffersDeleted.WithLabelValues("withdrawn"))
+		resp = sendJSON(t, app, http.MethodDelete, "/api/offers/"+id, "")
+		assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+		assert.Equal(t, withdrawn+1, testutil.ToFloat64(metrics.OffersDeleted.WithLabelValues("withdrawn")))
+
+		// Unknown and malformed IDs
+		for _, method := range []string{http.MethodGet, http.MethodPatch, http.MethodDelete} {
+			resp = sendJSON(t, app, method, "/api/offers/"+id, `{"price":1}`)
+			assert.Equal(t, http.StatusNotFound, resp.StatusCode, method)
+
+			resp = sendJSON(t, app, method, "/api/offers/no-uuid", `{"price":1}`)
+			assert.Equal(t, http.StatusBadRequest, resp.StatusCode, method)
+		}
+	})
+}
+
+func TestDeleteOffersByID(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, app *fiber.App) {
+		postOffers(t, app, []byte(testOffers))
+
+		resp := sendJSON(t, app, http.MethodPost, "/api/offers/delete", `{"ids":["1b993f42-6450-4ed1-a834-7c4a024f10f6","F63C607A-9140-42C8-BBE7-6E078CC54E75","1b993f42-6450-4ed1-a834-7c4a024f10f6","00000000-0000-0000-0000-000000000000"]}`)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		var result models.DeleteOffersResponse
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+		assert.Equal(t, models.DeleteOffersResponse{Deleted: 2, NotFound: []string{"00000000-0000-0000-0000-000000000000"}}, result)
+
+		response := getOffers(t, app, "regionID=18&timeRangeStart=0&timeRangeEnd=1692179200000&numberDays=1&sortOrder=price-asc&page=0&pageSize=10&priceRangeWidth=1000&minFreeKilometerWidth=1000")
+		assert.Empty(t, response.Offers)
+
+		// Nothing left to delete
+		resp = sendJSON(t, app, http.MethodPost, "/api/offers/delete", `{"ids":["1b993f42-6450-4ed1-a834-7c4a024f10f6"]}`)
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+		resp = sendJSON(t, app, http.MethodPost, "/api/offers/delete", `{"ids":["1b993f42-6450-4ed1-a834-7c4a024f10f6","no-uuid"]}`)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		assert.Equal(t, map[string]string{"ids[1]": "must be a UUID"}, decodeFieldErrors(t, resp))
+
+		resp = sendJSON(t, app, http.MethodPost, "/api/offers/delete", `{"ids":[]}`)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+}
+
+func TestDeleteMatchingOffers(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, app *fiber.App) {
+		postOffers(t, app, []byte(testOffers))
+		all := "regionID=0&timeRangeStart=0&timeRangeEnd=1692179200000&numberDays=0&sortOrder=price-asc&page=0&pageSize=100&priceRangeWidth=100000&minFreeKilometerWidth=1000"
+
+		deleteMatching := func(query string) models.OfferDeletionResponse {
+			resp := sendJSON(t, app, http.MethodDelete, "/api/offers/filter?"+query, "")
+			assert.Equal(t, http.StatusOK, resp.StatusCode, query)
+			var result models.OfferDeletionResponse
+			assert.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+			return result
+		}
+
+		// A dry run only counts the two offers below region 18
+		assert.Equal(t, models.OfferDeletionResponse{Matched: 2, DryRun: true}, deleteMatching("regionID=18&dryRun=true"))
+		assert.Len(t, getOffers(t, app, all).Offers, 10)
+
+		filtered := testutil.ToFloat64(metrics.OffersDeleted.WithLabelValues("filtered"))
+		assert.Equal(t, models.OfferDeletionResponse{Matched: 2, Deleted: 2}, deleteMatching("regionID=18"))
+		assert.Equal(t, filtered+2, testutil.ToFloat64(metrics.OffersDeleted.WithLabelValues("filtered")))
+
+		// Without regionID the filters apply in every region, only b7d5e28e of the small cars starts on June 12
+		assert.Equal(t, models.OfferDeletionResponse{Matched: 1, Deleted: 1}, deleteMatching("carType=small&timeRangeStart=1591920000000"))
+		assert.Equal(t, models.CarTypeCounts{Small: 1, Sports: 3, Family: 3}, getOffers(t, app, all).CarTypeCounts)
+
+		// Without any filter nothing is deleted
+		resp := sendJSON(t, app, http.MethodDelete, "/api/offers/filter?dryRun=true", "")
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		assert.Equal(t, map[string]string{"query": "at least one filter is required"}, decodeFieldErrors(t, resp))
+
+		resp = sendJSON(t, app, http.MethodDelete, "/api/offers/filter?carType=bus", "")
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		assert.Len(t, getOffers(t, app, all).Offers, 7)
+	})
+}
@@ -0,0 +1,246 @@
+//go:build integration
+
+// Die Tests gegen PostgreSQL laufen nur mit go test -tags integration ./tests und brauchen
+// eine Datenbank unter DATABASE_URL (bzw. der Konfiguration des Servers).
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"server/internal/config"
+	"server/internal/database"
+	"server/internal/framework"
+	"server/internal/models"
+	"server/internal/repository"
+	"testing"
+)
+
+// The stack tests run against PostgreSQL as well
+func init() {
+	testBackends["postgres"] = setupPostgresApp
+}
+
+// testDatabaseConfig connects to the database given by DATABASE_URL, like the server does
+func testDatabaseConfig(t *testing.T) config.Database {
+	cfg, _, err := config.Load(nil, os.LookupEnv)
+	if err != nil {
+		t.Fatalf("Invalid configuration: %v", err)
+	}
+	return cfg.Database
+}
+
+// connectTestDB connects to the test database and closes the pool when the test ends
+func connectTestDB(t *testing.T) *pgxpool.Pool {
+	dbPool, err := database.ConnectDB(context.Background(), testDatabaseConfig(t))
+	if err != nil {
+		t.Fatalf("Failed to connect to database: %v", err)
+	}
+	t.Cleanup(dbPool.Close)
+	return dbPool
+}
+
+// setupPostgresApp creates the application on top of an empty, freshly migrated database
+func setupPostgresApp(t *testing.T) *fiber.App {
+	ctx := context.Background()
+	dbPool := connectTestDB(t)
+
+	// clean up databases
+	if err := database.DropTables(ctx, dbPool); err != nil {
+		t.Fatalf("Failed to drop tables: %v", err)
+	}
+	rootRegion, err := database.LoadRegions("")
+	if err != nil {
+		t.Fatalf("Failed to load regions: %v", err)
+	}
+	if err := database.Migrate(ctx, dbPool, rootRegion); err != nil {
+		t.Fatalf("Failed to migrate database: %v", err)
+	}
+
+	app := framework.NewApp(framework.Dependencies{
+		Offers:     repository.NewOfferRepository(dbPool),
+		Regions:    repository.NewRegionRepository(dbPool),
+		RootRegion: rootRegion,
+		DBPool:     dbPool,
+		AdminToken: testAdminToken,
+	})
+	app.Health.SetReady(true)
+	return app.App
+}
+
+func TestSchemaMigrateAndRollback(t *testing.T) {
+	ctx := context.Background()
+	dbPool := connectTestDB(t)
+
+	assert.NoError(t, database.DropTables(ctx, dbPool))
+	assert.NoError(t, database.MigrateSchema(ctx, dbPool))
+
+	migrations, err := database.SchemaMigrations()
+	assert.NoError(t, err)
+	latest := migrations[len(migrations)-1].Version
+
+	version, err := database.SchemaVersion(ctx, dbPool)
+	assert.NoError(t, err)
+	assert.Equal(t, latest, version)
+
+	// Migrating again is a no-op
+	assert.NoError(t, database.MigrateSchema(ctx, dbPool))
+
+	assert.NoError(t, database.RollbackSchema(ctx, dbPool, len(migrations)))
+	version, err = database.SchemaVersion(ctx, dbPool)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, version)
+
+	assert.NoError(t, database.MigrateSchemaTo(ctx, dbPool, latest))
+	version, err = database.SchemaVersion(ctx, dbPool)
+	assert.NoError(t, err)
+	assert.Equal(t, latest, version)
+
+	assert.Error(t, database.MigrateSchemaTo(ctx, dbPool, latest+1000))
+}
+
+func TestAdvisoryLocker(t *testing.T) {
+	ctx := context.Background()
+	locker := database.NewAdvisoryLocker(connectTestDB(t))
+	other := database.NewAdvisoryLocker(connectTestDB(t))
+
+	unlock, ok, err := locker.TryLock(ctx, "test-job")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	// A second replica does not get the lock until it is released
+	_, ok, err = other.TryLock(ctx, "test-job")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	unlock()
+	unlockOther, ok, err := other.TryLock(ctx, "test-job")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	unlockOther()
+}
+
+// closurePairs counts the ancestor/descendant pairs of a tree, every region is its own ancestor
+func closurePairs(region database.Region, depth int) int {
+	pairs := depth + 1
+	for _, subregion := range region.Subregions {
+		pairs += closurePairs(subregion, depth+1)
+	}
+	return pairs
+}
+
+func TestRegionClosureFollowsRegionData(t *testing.T) {
+	ctx := context.Background()
+	app := setupPostgresApp(t)
+	dbPool := connectTestDB(t)
+
+	countClosure := func() int {
+		var count int
+		assert.NoError(t, dbPool.QueryRow(ctx, "SELECT COUNT(*) FROM region_closure").Scan(&count))
+		return count
+	}
+	searchRegion := func(regionID int) int {
+		query := fmt.Sprintf("regionID=%d&timeRangeStart=0&timeRangeEnd=1800000000000&numberDays=0&sortOrder=price-asc&page=0&pageSize=10&priceRangeWidth=100&minFreeKilometerWidth=100", regionID)
+		return len(getOffers(t, app, query).Offers)
+	}
+
+	rootRegion, err := database.LoadRegions("")
+	assert.NoError(t, err)
+	assert.Equal(t, closurePairs(rootRegion, 0), countClosure())
+
+	// The generated offer is in region 58
+	postOffers(t, app, generateOffers(1))
+	moved, formerParent, ok := detachRegion(&rootRegion, 58)
+	assert.True(t, ok)
+	assert.Equal(t, 1, searchRegion(formerParent))
+	assert.Equal(t, 0, searchRegion(18))
+
+	// Reloading the regions rebuilds the closure in the same transaction
+	assert.True(t, attachRegion(&rootRegion, 18, moved))
+	body, err := json.Marshal(rootRegion)
+	assert.NoError(t, err)
+	req := httptest.NewRequest(http.MethodPut, "/api/admin/regions", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+testAdminToken)
+	resp, err := app.Test(req, -1)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	assert.Equal(t, closurePairs(rootRegion, 0), countClosure())
+	assert.Equal(t, 0, searchRegion(formerParent))
+	assert.Equal(t, 1, searchRegion(18))
+	assert.Equal(t, 1, searchRegion(58))
+	assert.Equal(t, 1, searchRegion(0))
+}
+
+func TestSearchOffersWithoutFreeKilometers(t *testing.T) {
+	ctx := context.Background()
+	app := setupPostgresApp(t)
+	dbPool := connectTestDB(t)
+
+	// Rows written before the column was filled have no free kilometers
+	_, err := dbPool.Exec(ctx, `
+		INSERT INTO offers (id, data, most_specific_region_id, start_date, end_date, number_seats, price, car_type, only_vollkasko, free_kilometers)
+		VALUES ('8b0e2f5c-7f39-4d5e-9a43-4a8d8b1c2e01', 'x', 58, 1732060800000, 1732406400000, 5, 10000, 'luxury', true, NULL)`)
+	assert.NoError(t, err)
+
+	for _, sortOrder := range []string{"price-asc", "freeKilometers-desc"} {
+		response := getOffers(t, app, "regionID=58&timeRangeStart=0&timeRangeEnd=1800000000000&numberDays=1&sortOrder="+sortOrder+"&page=0&pageSize=10&priceRangeWidth=1000&minFreeKilometerWidth=100")
+		assert.Len(t, response.Offers, 1, sortOrder)
+		assert.Equal(t, []models.FreeKilometerRange{{Start: 0, End: 100, Count: 1}}, response.FreeKilometerRange, sortOrder)
+	}
+}
+
+func TestEstimateOffers(t *testing.T) {
+	ctx := context.Background()
+	app := setupPostgresApp(t)
+	dbPool := connectTestDB(t)
+	repo := repository.NewOfferRepository(dbPool)
+
+	postOffers(t, app, generateOffers(3))
+
+	// Before the first ANALYZE the estimate falls back to the live rows of the statistics
+	estimate, err := repo.EstimateOffers(ctx)
+	assert.NoError(t, err)
+	assert.GreaterOrEqual(t, estimate, 0)
+
+	_, err = dbPool.Exec(ctx, "ANALYZE offers")
+	assert.NoError(t, err)
+	estimate, err = repo.EstimateOffers(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, estimate)
+}
+
+func TestCreateOffersForRemovedRegionOnPostgres(t *testing.T) {
+	setupPostgresApp(t)
+	dbPool := connectTestDB(t)
+	checkOffersForRemovedRegion(t, repository.NewOfferRepository(dbPool), repository.NewRegionRepository(dbPool))
+}
+
+func TestRefreshRegionsOnPostgres(t *testing.T) {
+	setupPostgresApp(t)
+	checkRefreshRegions(t, repository.NewRegionRepository(connectTestDB(t)))
+}
+
+func TestRemoveReferencedRegionFailsOnForeignKey(t *testing.T) {
+	ctx := context.Background()
+	app := setupPostgresApp(t)
+	dbPool := connectTestDB(t)
+
+	// The offer is in region 58, the foreign key keeps the region even without the check of ReplaceRegions
+	postOffers(t, app, generateOffers(1))
+	_, err := dbPool.Exec(ctx, "DELETE FROM static_region_data WHERE id = 58")
+	assert.Error(t, err)
+
+	_, err = dbPool.Exec(ctx, `
+		INSERT INTO offers (id, data, most_specific_region_id, start_date, end_date, number_seats, price, car_type, only_vollkasko, free_kilometers)
+		VALUES ('8b0e2f5c-7f39-4d5e-9a43-4a8d8b1c2e02', 'x', 100000, 1732060800000, 1732406400000, 5, 10000, 'luxury', true, 120)`)
+	assert.Error(t, err)
+}
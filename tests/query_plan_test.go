@@ -0,0 +1,136 @@
+//go:build integration
+
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"net/url"
+	"os"
+	"server/internal/logging"
+	"strings"
+	"testing"
+)
+
+// planFixtureOffers is large enough that reading the whole offers table costs more than the indexes
+const planFixtureOffers = 200000
+
+// insertPlanFixtures spreads generated offers over all leaf regions and the year 2024
+const insertPlanFixtures = `
+	WITH leaves AS (
+		SELECT id, row_number() OVER (ORDER BY id) - 1 AS n, COUNT(*) OVER () AS total
+		FROM static_region_data r
+		WHERE NOT EXISTS (SELECT 1 FROM static_region_data c WHERE c.parent_id = r.id)
+	)
+	INSERT INTO offers (id, data, most_specific_region_id, start_date, end_date, number_seats, price, car_type, only_vollkasko, free_kilometers)
+	SELECT md5(i::text)::uuid::text, 'plan fixture', leaves.id,
+		1704067200000 + (i % 366) * 86400000::bigint,
+		1704067200000 + (i % 366 + 1 + i % 14) * 86400000::bigint,
+		2 + i % 6, 1000 + (i * 7919) % 50000, (ARRAY['small', 'sports', 'luxury', 'family'])[(1 + i % 4)::int],
+		i % 2 = 0, (i * 31) % 1000
+	FROM generate_series(1, $1) AS i
+	JOIN leaves ON leaves.n = i % leaves.total`
+
+// planNode is a node of EXPLAIN (FORMAT JSON)
+type planNode struct {
+	NodeType     string     `json:"Node Type"`
+	RelationName string     `json:"Relation Name"`
+	IndexName    string     `json:"Index Name"`
+	Plans        []planNode `json:"Plans"`
+}
+
+// offerIndexes lists the indexes of offers the plan reads, the index nodes of bitmap scans name no relation
+func (n planNode) offerIndexes() []string {
+	var indexes []string
+	if strings.HasPrefix(n.IndexName, "offers_") {
+		indexes = append(indexes, n.IndexName)
+	}
+	for _, child := range n.Plans {
+		indexes = append(indexes, child.offerIndexes()...)
+	}
+	return indexes
+}
+
+// seqScans lists the tables read by sequential scans in the plan
+func (n planNode) seqScans() []string {
+	var tables []string
+	if n.NodeType == "Seq Scan" {
+		tables = append(tables, n.RelationName)
+	}
+	for _, child := range n.Plans {
+		tables = append(tables, child.seqScans()...)
+	}
+	return tables
+}
+
+func TestSearchesUseIndexes(t *testing.T) {
+	ctx := context.Background()
+	app := setupPostgresApp(t)
+	dbPool := connectTestDB(t)
+
+	_, err := dbPool.Exec(ctx, insertPlanFixtures, planFixtureOffers)
+	assert.NoError(t, err)
+	_, err = dbPool.Exec(ctx, "VACUUM ANALYZE offers")
+	assert.NoError(t, err)
+
+	// Cursor pages add the keyset condition on the sort order
+	cursorQuery := "regionID=58&timeRangeStart=1717200000000&timeRangeEnd=1719792000000&numberDays=1&sortOrder=price-desc&page=0&pageSize=10&priceRangeWidth=1000&minFreeKilometerWidth=100&cursor="
+	firstPage := getOffers(t, app, cursorQuery)
+	assert.NotEmpty(t, firstPage.NextCursor)
+
+	// The query audit logs every statement of a search ready for EXPLAIN
+	var output bytes.Buffer
+	assert.NoError(t, logging.Setup(&output, "json", "info", true))
+	defer logging.Setup(os.Stderr, "text", "info", false)
+
+	// Searches of a region read its offers through the region index. The price indexes cannot apply
+	// the region or the time window, reading them would filter out almost every row.
+	searches := []struct {
+		name   string
+		query  string
+		region bool
+	}{
+		{"leaf region, one week", "regionID=58&timeRangeStart=1717200000000&timeRangeEnd=1717804800000&numberDays=2&sortOrder=price-asc&page=0&pageSize=10&priceRangeWidth=1000&minFreeKilometerWidth=100", true},
+		{"subtree with filters", "regionID=18&timeRangeStart=1717200000000&timeRangeEnd=1719792000000&numberDays=3&sortOrder=price-desc&page=2&pageSize=20&priceRangeWidth=500&minFreeKilometerWidth=50&carType=family&minNumberSeats=4&onlyVollkasko=true", true},
+		{"all regions, three days", "regionID=0&timeRangeStart=1717200000000&timeRangeEnd=1717459200000&numberDays=1&sortOrder=price-asc&page=0&pageSize=100&priceRangeWidth=1000&minFreeKilometerWidth=100", false},
+		{"leaf region, cursor page", cursorQuery + url.QueryEscape(firstPage.NextCursor), true},
+	}
+	for _, search := range searches {
+		name := search.name
+		output.Reset()
+		getOffers(t, app, search.query)
+
+		statements := 0
+		for _, line := range strings.Split(strings.TrimSpace(output.String()), "\n") {
+			var record struct {
+				Msg       string `json:"msg"`
+				Statement string `json:"statement"`
+				SQL       string `json:"sql"`
+			}
+			if err := json.Unmarshal([]byte(line), &record); err != nil || record.Msg != "Query audit" {
+				continue
+			}
+			statements++
+
+			var plan []struct {
+				Plan planNode `json:"Plan"`
+			}
+			var explained []byte
+			if !assert.NoError(t, dbPool.QueryRow(ctx, "EXPLAIN (FORMAT JSON) "+record.SQL).Scan(&explained), name) ||
+				!assert.NoError(t, json.Unmarshal(explained, &plan), name) {
+				continue
+			}
+			assert.NotContains(t, plan[0].Plan.seqScans(), "offers", "%s: %s reads the whole offers table:\n%s", name, record.Statement, explained)
+			if search.region {
+				indexes := plan[0].Plan.offerIndexes()
+				assert.NotEmpty(t, indexes, "%s: %s reads offers without index:\n%s", name, record.Statement, explained)
+				for _, index := range indexes {
+					assert.Equal(t, "offers_region_dates_idx", index, "%s: %s reads offers without the region index:\n%s", name, record.Statement, explained)
+				}
+			}
+		}
+		assert.Equal(t, 2, statements, "%s: expected the page and the facet statement in the audit log", name)
+	}
+}
@@ -0,0 +1,336 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"server/internal/database"
+	"server/internal/framework"
+	"server/internal/models"
+	"server/internal/regions"
+	"server/internal/repository"
+	"server/internal/service"
+	"server/internal/validation"
+	"strings"
+	"testing"
+)
+
+func TestGetRegionTree(t *testing.T) {
+	app := setupMemoryApp()
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/api/regions", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var root database.Region
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&root))
+	assert.Equal(t, testRegionTree(), root)
+}
+
+func TestGetRegion(t *testing.T) {
+	app := setupMemoryApp()
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/api/regions/4", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var region models.RegionDetail
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&region))
+	assert.Equal(t, "A2", region.Name)
+	if assert.NotNil(t, region.ParentID) {
+		assert.Equal(t, 1, *region.ParentID)
+	}
+	if assert.Len(t, region.Ancestors, 2) {
+		assert.Equal(t, 0, region.Ancestors[0].ID)
+		assert.Nil(t, region.Ancestors[0].ParentID)
+		assert.Equal(t, 1, region.Ancestors[1].ID)
+	}
+	assert.Empty(t, region.Subregions)
+
+	resp, err = app.Test(httptest.NewRequest(http.MethodGet, "/api/regions/2", nil))
+	assert.NoError(t, err)
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&region))
+	assert.Equal(t, []database.Region{{ID: 5, Name: "B1"}}, region.Subregions)
+
+	resp, err = app.Test(httptest.NewRequest(http.MethodGet, "/api/regions/99", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	resp, err = app.Test(httptest.NewRequest(http.MethodGet, "/api/regions/abc", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestSearchRegions(t *testing.T) {
+	app := setupMemoryApp()
+
+	search := func(query string) []models.RegionSummary {
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/api/regions/search?"+query, nil))
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var results []models.RegionSummary
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&results))
+		return results
+	}
+	assert.Equal(t, []int{1, 3, 4}, regionIDs(search("name=a")))
+	assert.Equal(t, []int{2, 5}, regionIDs(search("name=b")))
+	assert.Equal(t, []int{3, 5}, regionIDs(search("name=1")))
+	assert.Equal(t, []int{1, 3}, regionIDs(search("name=a&limit=2")))
+	assert.Equal(t, []int{0}, regionIDs(search("name=OO")))
+	assert.Empty(t, search("name=xyz"))
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/api/regions/search?limit=0", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	fields := decodeFieldErrors(t, resp)
+	assert.Contains(t, fields, "name")
+	assert.Contains(t, fields, "limit")
+}
+
+func TestRegionFlattenAndBuild(t *testing.T) {
+	flat := regions.Flatten(testRegionTree())
+	list := make([]models.RegionSummary, 0, len(flat))
+	for id := 0; id < len(flat); id++ {
+		list = append(list, flat[id])
+	}
+
+	root, err := regions.Build(list)
+	assert.NoError(t, err)
+	assert.Equal(t, testRegionTree(), root)
+
+	parentID := 42
+	_, err = regions.Build(append(list, models.RegionSummary{ID: 7, Name: "Orphan", ParentID: &parentID}))
+	assert.Error(t, err)
+}
+
+func TestReloadRegions(t *testing.T) {
+	forEachBackend(t, testReloadRegions)
+}
+
+func testReloadRegions(t *testing.T, app *fiber.App) {
+	putRegions := func(body string) *http.Response {
+		req := httptest.NewRequest(http.MethodPut, "/api/admin/regions", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+testAdminToken)
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		return resp
+	}
+	searchRegion := func(regionID string) []string {
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/api/offers?regionID="+regionID+"&timeRangeStart=0&timeRangeEnd=1692179200000&numberDays=1&sortOrder=price-asc&page=0&pageSize=10&priceRangeWidth=10&minFreeKilometerWidth=10", nil))
+		assert.NoError(t, err)
+		var search models.OfferQueryResponse
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&search))
+		return offerIDs(search.Offers)
+	}
+
+	// Start from the small test tree, the backends are seeded with the tree of the platform
+	testTree, err := json.Marshal(testRegionTree())
+	assert.NoError(t, err)
+	resp := putRegions(string(testTree))
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	body := `{"offers":[
+		{"ID":"68ed0a29-a7ae-42b4-bdfd-2f35462828ca","data":"x","mostSpecificRegionID":3,"startDate":1591920000000,"endDate":1592179200000,"numberSeats":2,"price":4481,"carType":"sports","hasVollkasko":true,"freeKilometers":508},
+		{"ID":"b2d5a1f0-0d1c-4a57-9d0e-6c1c3a3c6a11","data":"x","mostSpecificRegionID":4,"startDate":1591920000000,"endDate":1592179200000,"numberSeats":2,"price":4481,"carType":"sports","hasVollkasko":true,"freeKilometers":508}
+	]}`
+	assert.Equal(t, 2, postOffers(t, app, []byte(body)).Inserted)
+
+	// Region 3 still has an offer, so nothing may change
+	resp = putRegions(`{"id":0,"name":"Root","subregions":[{"id":1,"name":"A","subregions":[{"id":4,"name":"A2"}]},{"id":2,"name":"B","subregions":[{"id":5,"name":"B1"}]}]}`)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+
+	resp, err = app.Test(httptest.NewRequest(http.MethodGet, "/api/regions", nil))
+	assert.NoError(t, err)
+	var root database.Region
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&root))
+	assert.Equal(t, testRegionTree(), root)
+
+	// Rename A, move A2 below B and add C
+	resp = putRegions(`{"id":0,"name":"Root","subregions":[{"id":1,"name":"Alpha","subregions":[{"id":3,"name":"A1"}]},{"id":2,"name":"B","subregions":[{"id":5,"name":"B1"},{"id":4,"name":"A2"}]},{"id":6,"name":"C"}]}`)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var diff models.RegionDiff
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&diff))
+	assert.Equal(t, []int{6}, regionIDs(diff.Added))
+	assert.Equal(t, []int{1}, regionIDs(diff.Renamed))
+	assert.Equal(t, []int{4}, regionIDs(diff.Moved))
+	assert.Empty(t, diff.Removed)
+
+	assert.Equal(t, []string{"68ed0a29-a7ae-42b4-bdfd-2f35462828ca"}, searchRegion("1"))
+	assert.Equal(t, []string{"b2d5a1f0-0d1c-4a57-9d0e-6c1c3a3c6a11"}, searchRegion("2"))
+
+	resp, err = app.Test(httptest.NewRequest(http.MethodGet, "/api/regions/4", nil))
+	assert.NoError(t, err)
+	var region models.RegionDetail
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&region))
+	assert.Equal(t, []int{0, 2}, regionIDs(region.Ancestors))
+
+	// Removing the empty region C succeeds
+	resp = putRegions(`{"id":0,"name":"Root","subregions":[{"id":1,"name":"Alpha","subregions":[{"id":3,"name":"A1"}]},{"id":2,"name":"B","subregions":[{"id":5,"name":"B1"},{"id":4,"name":"A2"}]}]}`)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&diff))
+	assert.Equal(t, []int{6}, regionIDs(diff.Removed))
+
+	// Offers for the removed region are rejected one by one
+	created := postOffers(t, app, []byte(`{"offers":[{"ID":"0c4f7a52-3d1e-4b8a-9f6e-2a7b5c9d1e03","data":"x","mostSpecificRegionID":6,"startDate":1591920000000,"endDate":1592179200000,"numberSeats":2,"price":4481,"carType":"sports","hasVollkasko":true,"freeKilometers":508}]}`))
+	assert.Equal(t, 0, created.Inserted)
+	if assert.Len(t, created.Rejected, 1) {
+		assert.Equal(t, []string{"mostSpecificRegionID: must reference an existing leaf region"}, created.Rejected[0].Errors)
+	}
+
+	resp = putRegions(`{"id":0,"name":"Root","subregions":[{"id":1,"name":""},{"id":1,"name":"B"}]}`)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	fields := decodeFieldErrors(t, resp)
+	assert.Contains(t, fields, "region.subregions[0].name")
+	assert.Contains(t, fields, "region.subregions[1].id")
+}
+
+// detachRegion removes the region from the tree and returns it with the ID of its former parent
+func detachRegion(region *database.Region, id int) (database.Region, int, bool) {
+	for i, subregion := range region.Subregions {
+		if subregion.ID == id {
+			region.Subregions = append(region.Subregions[:i], region.Subregions[i+1:]...)
+			return subregion, region.ID, true
+		}
+		if detached, parentID, ok := detachRegion(&region.Subregions[i], id); ok {
+			return detached, parentID, true
+		}
+	}
+	return database.Region{}, 0, false
+}
+
+// attachRegion appends child to the subregions of the region with the given ID
+func attachRegion(region *database.Region, parentID int, child database.Region) bool {
+	if region.ID == parentID {
+		region.Subregions = append(region.Subregions, child)
+		return true
+	}
+	for i := range region.Subregions {
+		if attachRegion(&region.Subregions[i], parentID, child) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkOffersForRemovedRegion writes offers through a service whose hierarchy still contains
+// region 58 after it was removed from the repository, like on a replica that did not refresh yet
+func checkOffersForRemovedRegion(t *testing.T, offerRepo repository.OfferRepository, regionRepo repository.RegionRepository) {
+	ctx := context.Background()
+	rootRegion, err := database.LoadRegions("")
+	assert.NoError(t, err)
+	offerService := service.NewOfferService(offerRepo, regions.NewHierarchy(regions.NewIndex(rootRegion)))
+
+	reduced, err := database.LoadRegions("")
+	assert.NoError(t, err)
+	_, _, ok := detachRegion(&reduced, 58)
+	assert.True(t, ok)
+	_, err = regionRepo.ReplaceRegions(ctx, reduced)
+	assert.NoError(t, err)
+
+	offer := func(id string, regionID int) models.Offer {
+		return models.Offer{ID: id, Data: "x", MostSpecificRegionID: regionID, StartDate: 1591920000000, EndDate: 1592179200000,
+			NumberSeats: 2, Price: 4481, CarType: "sports", OnlyVollkasko: true, FreeKilometers: 508}
+	}
+	response, err := offerService.CreateOffers(ctx, []models.Offer{
+		offer("3f0c1f9e-8d7b-4c2a-9e61-5b4a3c2d1e01", 58),
+		offer("3f0c1f9e-8d7b-4c2a-9e61-5b4a3c2d1e02", 81),
+	}, nil, models.ConflictReject)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, response.Inserted)
+	if assert.Len(t, response.Rejected, 1) {
+		assert.Equal(t, 0, response.Rejected[0].Index)
+		assert.Equal(t, []string{"mostSpecificRegionID: must reference an existing leaf region"}, response.Rejected[0].Errors)
+	}
+
+	// Moving an offer into the removed region is invalid as well
+	region := 58
+	_, err = offerService.UpdateOffer(ctx, "3f0c1f9e-8d7b-4c2a-9e61-5b4a3c2d1e02", models.OfferPatch{MostSpecificRegionID: &region})
+	var errs validation.Errors
+	if assert.ErrorAs(t, err, &errs) {
+		assert.Equal(t, validation.UnknownRegion(), errs)
+	}
+}
+
+func TestCreateOffersForRemovedRegion(t *testing.T) {
+	rootRegion, err := database.LoadRegions("")
+	assert.NoError(t, err)
+	offerRepo := repository.NewOfferMemoryRepository(regions.NewIndex(rootRegion))
+	checkOffersForRemovedRegion(t, offerRepo, repository.NewRegionMemoryRepository(offerRepo))
+}
+
+// checkRefreshRegions reloads the regions through one service and lets a second one, standing
+// for another replica on the same storage, pick them up
+func checkRefreshRegions(t *testing.T, regionRepo repository.RegionRepository) {
+	ctx := context.Background()
+	rootRegion, err := regionRepo.GetRegions(ctx)
+	assert.NoError(t, err)
+	replica := service.NewRegionService(regions.NewHierarchy(regions.NewIndex(rootRegion)), regionRepo)
+	_, err = replica.RefreshRegions(ctx)
+	assert.NoError(t, err)
+
+	changed, err := replica.RefreshRegions(ctx)
+	assert.NoError(t, err)
+	assert.False(t, changed)
+
+	reloading := service.NewRegionService(regions.NewHierarchy(regions.NewIndex(rootRegion)), regionRepo)
+	_, err = reloading.ReloadRegions(ctx, testRegionTree())
+	assert.NoError(t, err)
+
+	changed, err = replica.RefreshRegions(ctx)
+	assert.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, testRegionTree(), replica.GetRegionTree())
+}
+
+func TestRefreshRegions(t *testing.T) {
+	rootRegion, err := database.LoadRegions("")
+	assert.NoError(t, err)
+	checkRefreshRegions(t, repository.NewRegionMemoryRepository(repository.NewOfferMemoryRepository(regions.NewIndex(rootRegion))))
+}
+
+func regionIDs(regions []models.RegionSummary) []int {
+	ids := []int{}
+	for _, region := range regions {
+		ids = append(ids, region.ID)
+	}
+	return ids
+}
+
+func TestAdminRoutesRequireToken(t *testing.T) {
+	app := setupMemoryApp()
+	body := `{"id":0,"name":"Root"}`
+
+	for _, header := range []string{"", "Bearer wrong-token-0123456789", testAdminToken} {
+		req := httptest.NewRequest(http.MethodPut, "/api/admin/regions", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if header != "" {
+			req.Header.Set("Authorization", header)
+		}
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode, header)
+	}
+
+	// The region tree is unchanged
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/api/regions", nil))
+	assert.NoError(t, err)
+	var root database.Region
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&root))
+	assert.Equal(t, testRegionTree(), root)
+
+	// Without a configured token the admin routes are disabled
+	disabled := fiber.New()
+	disabled.Use(framework.AdminAuth(""))
+	disabled.Put("/api/admin/regions", func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) })
+	req := httptest.NewRequest(http.MethodPut, "/api/admin/regions", strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer ")
+	resp, err = disabled.Test(req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+}
@@ -0,0 +1,90 @@
+package tests
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"server/internal/database"
+	"server/internal/replay"
+	"strings"
+	"testing"
+	"time"
+)
+
+// The evaluation log of the platform is the golden reference for search results
+func TestReplayEvaluationLog(t *testing.T) {
+	file, err := os.Open("../log_parser/01935ac9-1473-78bf-93c3-15ebc9a5ed2c-1.log")
+	if err != nil {
+		t.Fatalf("Failed to open log: %v", err)
+	}
+	defer file.Close()
+
+	entries, err := replay.ReadLog(file)
+	assert.NoError(t, err)
+
+	rootRegion, err := database.LoadRegions("")
+	assert.NoError(t, err)
+
+	report, err := replay.Run(context.Background(), replay.AppClient{App: setupMemoryAppWithRegions(rootRegion)}, "", entries)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, report.Pushes)
+	assert.Equal(t, 7, report.Reads)
+	assert.Empty(t, report.Failures)
+	assert.Len(t, report.Latencies[replay.RequestRead], 7)
+}
+
+// A PUSH that failed in the original run is not replayed, its offers must not show up in searches
+func TestReplaySkipsFailedPush(t *testing.T) {
+	log := `{"requestType":"PUSH","timestamp":"1","log":{"id":"p1","duration":5,"write_config":{"ID":"p1","Offers":[{"OfferID":"26e52100-fd5e-40d3-b815-fd4e44349a00","RegionID":57,"CarType":"family","NumberDays":4,"NumberSeats":6,"StartTimestamp":"2021-01-21T00:00:00Z","EndTimestamp":"2021-01-25T00:00:00Z","Price":3416,"HasVollkasko":false,"FreeKilometers":139}]},"write_error":{"error":"failed to create offers: received status code != 200","response_code":500}}}
+{"requestType":"PUSH","timestamp":"2","log":{"id":"p2","duration":5,"write_config":{"ID":"p2","Offers":[{"OfferID":"8c210c3f-9220-423b-b63a-7cb0c8395fe9","RegionID":57,"CarType":"sports","NumberDays":4,"NumberSeats":5,"StartTimestamp":"2021-01-21T00:00:00Z","EndTimestamp":"2021-01-25T00:00:00Z","Price":3689,"HasVollkasko":true,"FreeKilometers":506}]},"write_error":null}}
+{"requestType":"READ","timestamp":"3","log":{"id":"r1","duration":3,"expected_result":{"Offers":[{"OfferID":"8c210c3f-9220-423b-b63a-7cb0c8395fe9","IsDataCorrect":true}],"CarTypeCounts":{"family":0,"luxury":0,"small":0,"sports":1},"FreeKilometerRanges":[{"Start":500,"End":550,"Count":1}],"PriceRanges":[{"Start":3680,"End":3690,"Count":1}],"SeatsCounts":{"5":1},"VollkaskoCount":{"TrueCount":1,"FalseCount":0}},"search_config":{"ID":"r1","RegionID":0,"StartRange":"2021-01-21T00:00:00Z","EndRange":"2021-01-25T00:00:00Z","NumberDays":4,"Pagination":{"Page":0,"PageSize":100},"Order":"price-asc","PriceBucketWidth":10,"FreeKmBucketWidth":50}}}
+`
+	entries, err := replay.ReadLog(strings.NewReader(log))
+	assert.NoError(t, err)
+
+	rootRegion, err := database.LoadRegions("")
+	assert.NoError(t, err)
+
+	report, err := replay.Run(context.Background(), replay.AppClient{App: setupMemoryAppWithRegions(rootRegion)}, "", entries)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, report.Pushes)
+	assert.Equal(t, 1, report.Reads)
+	assert.Equal(t, 1, report.Skipped)
+	assert.Empty(t, report.Failures)
+	assert.Len(t, report.Recorded[replay.RequestPush], 1)
+}
+
+func TestReplayDiff(t *testing.T) {
+	expected := replay.Result{
+		Offers:         []replay.ResultOffer{{OfferID: "a", IsDataCorrect: true}},
+		CarTypeCounts:  map[string]int{"small": 1, "sports": 0, "luxury": 0, "family": 0},
+		PriceRanges:    []replay.Range{{Start: 100, End: 110, Count: 1}},
+		SeatsCounts:    map[string]int{"4": 1},
+		VollkaskoCount: replay.VollkaskoCount{TrueCount: 1},
+	}
+	actual := expected
+	actual.Offers = []replay.ResultOffer{{OfferID: "a", IsDataCorrect: false}}
+	actual.SeatsCounts = map[string]int{"5": 1}
+	actual.FreeKilometerRanges = []replay.Range{}
+
+	// An empty list equals a missing one, every other difference is reported per facet
+	mismatches := replay.Diff(expected, actual)
+	facets := make([]string, 0, len(mismatches))
+	for _, mismatch := range mismatches {
+		facets = append(facets, mismatch.Facet)
+	}
+	assert.Equal(t, []string{"Offers", "SeatsCounts"}, facets)
+	assert.Empty(t, replay.Diff(expected, expected))
+}
+
+func TestReplayPercentile(t *testing.T) {
+	var latencies []time.Duration
+	for i := 100; i >= 1; i-- {
+		latencies = append(latencies, time.Duration(i)*time.Millisecond)
+	}
+
+	assert.Equal(t, 50*time.Millisecond, replay.Percentile(latencies, 50))
+	assert.Equal(t, 99*time.Millisecond, replay.Percentile(latencies, 99))
+	assert.Equal(t, 100*time.Millisecond, replay.Percentile(latencies, 100))
+	assert.Equal(t, time.Duration(0), replay.Percentile(nil, 50))
+}
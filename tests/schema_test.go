@@ -0,0 +1,78 @@
+package tests
+
+import (
+	"context"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"server/internal/database"
+	"server/internal/framework"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoadSchemaMigrations(t *testing.T) {
+	migrations, err := database.LoadSchemaMigrations(fstest.MapFS{
+		"0002_add_index.up.sql":       {Data: []byte("CREATE INDEX a ON offers (price);")},
+		"0002_add_index.down.sql":     {Data: []byte("DROP INDEX a;")},
+		"0001_create_tables.up.sql":   {Data: []byte("CREATE TABLE offers ();")},
+		"0001_create_tables.down.sql": {Data: []byte("DROP TABLE offers;")},
+		"README.md":                   {Data: []byte("ignored")},
+	})
+	assert.NoError(t, err)
+	if assert.Len(t, migrations, 2) {
+		assert.Equal(t, 1, migrations[0].Version)
+		assert.Equal(t, "create_tables", migrations[0].Name)
+		assert.Equal(t, "DROP INDEX a;", migrations[1].Down)
+	}
+
+	_, err = database.LoadSchemaMigrations(fstest.MapFS{
+		"0001_create_tables.up.sql": {Data: []byte("CREATE TABLE offers ();")},
+	})
+	assert.Error(t, err, "a migration without down script must be rejected")
+
+	_, err = database.LoadSchemaMigrations(fstest.MapFS{
+		"0001_a.up.sql":   {Data: []byte("SELECT 1;")},
+		"0001_b.down.sql": {Data: []byte("SELECT 1;")},
+	})
+	assert.Error(t, err, "two migrations with the same version must be rejected")
+
+	// The embedded migrations are complete
+	migrations, err = database.SchemaMigrations()
+	assert.NoError(t, err)
+	assert.NotEmpty(t, migrations)
+}
+
+func TestRollbackSchemaRejectsInvalidSteps(t *testing.T) {
+	// The steps are checked before the database is touched
+	assert.Error(t, database.RollbackSchema(context.Background(), nil, 0))
+	assert.Error(t, database.RollbackSchema(context.Background(), nil, -1))
+}
+
+func TestLoadRegions(t *testing.T) {
+	root, err := database.LoadRegions("")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, root.ID)
+	assert.NotEmpty(t, root.Subregions)
+
+	path := filepath.Join(t.TempDir(), "regions.json")
+	assert.NoError(t, os.WriteFile(path, []byte(`{"id":7,"name":"Custom","subregions":[{"id":8,"name":"Leaf"}]}`), 0o644))
+	root, err = database.LoadRegions(path)
+	assert.NoError(t, err)
+	assert.Equal(t, database.Region{ID: 7, Name: "Custom", Subregions: []database.Region{{ID: 8, Name: "Leaf"}}}, root)
+
+	_, err = database.LoadRegions(filepath.Join(t.TempDir(), "missing.json"))
+	assert.Error(t, err)
+}
+
+func TestSwaggerServesEmbeddedSpec(t *testing.T) {
+	app := fiber.New()
+	framework.RegisterSwagger(app)
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/docs/openapi.yaml", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
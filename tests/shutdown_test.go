@@ -0,0 +1,41 @@
+package tests
+
+import (
+	"context"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"server/internal/framework"
+	"testing"
+	"time"
+)
+
+// Canceling the base context aborts running requests, so they release their database
+// connections when the shutdown deadline passed
+func TestRequestContextCanceledOnShutdown(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	app := fiber.New()
+	framework.RegisterRequestContext(app, ctx)
+	framework.RegisterLogging(app)
+	framework.RegisterTracing(app)
+
+	started := make(chan struct{})
+	app.Get("/slow", func(c *fiber.Ctx) error {
+		close(started)
+		select {
+		case <-c.UserContext().Done():
+			return c.SendStatus(fiber.StatusServiceUnavailable)
+		case <-time.After(5 * time.Second):
+			return c.SendStatus(fiber.StatusOK)
+		}
+	})
+
+	go func() {
+		<-started
+		cancel()
+	}()
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/slow", nil), -1)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+}
@@ -0,0 +1,47 @@
+package tests
+
+import (
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTracingSpans(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	app := setupMemoryApp()
+
+	req := httptest.NewRequest(http.MethodGet, "/api/offers?regionID=1&timeRangeStart=0&timeRangeEnd=1692179200000&numberDays=1&sortOrder=price-asc&page=0&pageSize=10&priceRangeWidth=10&minFreeKilometerWidth=10", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	resp, err := app.Test(req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	spans := make(map[string]sdktrace.ReadOnlySpan)
+	for _, span := range recorder.Ended() {
+		spans[span.Name()] = span
+	}
+
+	server, ok := spans["GET /api/offers"]
+	if !assert.True(t, ok, "server span") {
+		return
+	}
+	service := spans["OfferService.GetOffers"]
+	repository := spans["offerMemoryRepository.GetOffers"]
+	if !assert.NotNil(t, service) || !assert.NotNil(t, repository) {
+		return
+	}
+
+	// The request continues the trace of the caller and every layer is a child of the one above
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", server.SpanContext().TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", server.Parent().SpanID().String())
+	assert.Equal(t, server.SpanContext().SpanID(), service.Parent().SpanID())
+	assert.Equal(t, service.SpanContext().SpanID(), repository.Parent().SpanID())
+}
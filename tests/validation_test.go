@@ -0,0 +1,157 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"server/internal/models"
+	"server/internal/validation"
+	"testing"
+)
+
+func decodeFieldErrors(t *testing.T, resp *http.Response) map[string]string {
+	var body struct {
+		Fields validation.Errors `json:"fields"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("Error decoding response body: %v", err)
+	}
+
+	fields := make(map[string]string)
+	for _, fieldError := range body.Fields {
+		fields[fieldError.Field] = fieldError.Message
+	}
+	return fields
+}
+
+func TestGetOffersValidation(t *testing.T) {
+	app := setupMemoryApp()
+
+	req := httptest.NewRequest(http.MethodGet, "/api/offers?regionID=abc&timeRangeStart=10&timeRangeEnd=5&numberDays=1&sortOrder=price&page=0&pageSize=-1&priceRangeWidth=0&carType=bus&onlyVollkasko=maybe", nil)
+	resp, err := app.Test(req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	fields := decodeFieldErrors(t, resp)
+	for _, field := range []string{"regionID", "timeRangeEnd", "sortOrder", "pageSize", "priceRangeWidth", "minFreeKilometerWidth", "carType", "onlyVollkasko"} {
+		assert.Contains(t, fields, field)
+	}
+	assert.NotContains(t, fields, "page")
+	assert.NotContains(t, fields, "numberDays")
+}
+
+func TestGetOffersPageLimits(t *testing.T) {
+	app := setupMemoryApp()
+	search := "/api/offers?regionID=0&timeRangeStart=0&timeRangeEnd=100&numberDays=1&sortOrder=price-asc&priceRangeWidth=10&minFreeKilometerWidth=10"
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, search+"&page=0&pageSize=1000", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp, err = app.Test(httptest.NewRequest(http.MethodGet, search+"&page=0&pageSize=1001", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Contains(t, decodeFieldErrors(t, resp), "pageSize")
+
+	// The offset of the last page would overflow
+	resp, err = app.Test(httptest.NewRequest(http.MethodGet, search+"&page=4294967295&pageSize=1000", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Contains(t, decodeFieldErrors(t, resp), "page")
+}
+
+func TestGetOffersValidParameters(t *testing.T) {
+	app := setupMemoryApp()
+
+	req := httptest.NewRequest(http.MethodGet, "/api/offers?regionID=0&timeRangeStart=0&timeRangeEnd=100&numberDays=1&sortOrder=price-desc&page=0&pageSize=10&priceRangeWidth=10&minFreeKilometerWidth=10&minPrice=0&carType=small&onlyVollkasko=true", nil)
+	resp, err := app.Test(req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestPostOffersValidation(t *testing.T) {
+	app := setupMemoryApp()
+
+	post := func(body string) *http.Response {
+		req := httptest.NewRequest(http.MethodPost, "/api/offers", bytes.NewReader([]byte(body)))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		return resp
+	}
+
+	// Only a body that cannot be parsed or holds no offers fails as a whole
+	for body, field := range map[string]string{
+		`{"offers":[`:     "body",
+		`{"offers":[]}`:   "offers",
+		`{"offers":5}`:    "offers",
+		`{"offers":null}`: "offers",
+	} {
+		resp := post(body)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, body)
+		assert.Contains(t, decodeFieldErrors(t, resp), field, body)
+	}
+
+	// Schema violations reject their own offer only
+	resp := post(`{"offers":[
+		{"ID":"a","data":"x","mostSpecificRegionID":3,"startDate":0,"endDate":1,"numberSeats":300,"price":100,"carType":"small","freeKilometers":1},
+		{"ID":"b","price":"cheap"},
+		"offer",
+		{"ID":"68ed0a29-a7ae-42b4-bdfd-2f35462828ca","data":"x","mostSpecificRegionID":3,"startDate":1591920000000,"endDate":1592179200000,"numberSeats":2,"price":4481,"carType":"sports","hasVollkasko":true,"freeKilometers":508}
+	]}`)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var result models.CreateOffersResponse
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+	assert.Equal(t, 1, result.Inserted)
+	if assert.Len(t, result.Rejected, 3) {
+		assert.Equal(t, 0, result.Rejected[0].Index)
+		assert.Equal(t, "a", result.Rejected[0].ID)
+		assert.ElementsMatch(t, []string{"numberSeats: must be between 0 and 255", "hasVollkasko: is required"}, result.Rejected[0].Errors)
+		assert.Equal(t, 1, result.Rejected[1].Index)
+		assert.Equal(t, []string{"price: must be of type int64"}, result.Rejected[1].Errors)
+		assert.Equal(t, 2, result.Rejected[2].Index)
+		assert.Equal(t, []string{"offer: must be an object"}, result.Rejected[2].Errors)
+	}
+}
+
+func TestPostOffersRejectsPerItem(t *testing.T) {
+	app := setupMemoryApp()
+
+	body := `{"offers":[
+		{"ID":"68ED0A29-A7AE-42B4-BDFD-2F35462828CA","data":"x","mostSpecificRegionID":3,"startDate":1591920000000,"endDate":1592179200000,"numberSeats":2,"price":4481,"carType":"sports","hasVollkasko":true,"freeKilometers":508},
+		{"ID":"no-uuid","data":"x","mostSpecificRegionID":1,"startDate":1592179200000,"endDate":1591920000001,"numberSeats":0,"price":0,"carType":"bus","hasVollkasko":true,"freeKilometers":508}
+	]}`
+	req := httptest.NewRequest(http.MethodPost, "/api/offers", bytes.NewReader([]byte(body)))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := app.Test(req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var result models.CreateOffersResponse
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+	assert.Equal(t, 1, result.Inserted)
+	if assert.Len(t, result.Rejected, 1) {
+		assert.Equal(t, 1, result.Rejected[0].Index)
+		assert.ElementsMatch(t, []string{
+			"ID: must be a UUID",
+			"endDate: must be a whole day",
+			"endDate: must be after startDate",
+			"carType: unsupported value \"bus\"",
+			"price: must be positive",
+			"numberSeats: must be positive",
+			"mostSpecificRegionID: must reference an existing leaf region",
+		}, result.Rejected[0].Errors)
+	}
+
+	// The accepted offer is stored with its normalized ID
+	req = httptest.NewRequest(http.MethodGet, "/api/offers?regionID=0&timeRangeStart=0&timeRangeEnd=1692179200000&numberDays=1&sortOrder=price-asc&page=0&pageSize=10&priceRangeWidth=10&minFreeKilometerWidth=10", nil)
+	resp, err = app.Test(req)
+	assert.NoError(t, err)
+
+	var search models.OfferQueryResponse
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&search))
+	assert.Equal(t, []string{"68ed0a29-a7ae-42b4-bdfd-2f35462828ca"}, offerIDs(search.Offers))
+}